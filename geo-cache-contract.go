@@ -6,13 +6,19 @@ package main
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"math"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"golang.org/x/crypto/pbkdf2"
 )
 
 // GeoCacheContract contract for managing CRUD for GeoCache
@@ -20,6 +26,355 @@ type GeoCacheContract struct {
 	contractapi.Contract
 }
 
+//the minimum age of a registered account before it can create caches
+const minAccountAgeSeconds = 60 * 60 * 24
+
+//the MSP that is allowed to call the administrative functions
+const adminMSPID = "AdminMSP"
+
+//the maximum number of caches a single owner can hold
+const maxCachesPerOwner = 10
+
+//the number of ratings a cache needs before it can appear in the top rated list
+const minRatingsToQualify = 2
+
+//the lifecycle states of a cache, the empty legacy status counts as active
+const statusActive = "ACTIVE"
+const statusDisabled = "DISABLED"
+const statusArchived = "ARCHIVED"
+
+//the severity levels a report can carry, the empty legacy severity counts as unspecified
+const severityLow = "LOW"
+const severityMedium = "MEDIUM"
+const severityHigh = "HIGH"
+
+//the allowed container size classifications, the empty legacy size counts as unclassified
+const sizeMicro = "micro"
+const sizeSmall = "small"
+const sizeRegular = "regular"
+const sizeLarge = "large"
+const sizeOther = "other"
+
+//statusNeedsMaintenance marks a cache that was reported by enough distinct users
+const statusNeedsMaintenance = "NEEDS_MAINTENANCE"
+
+//needsMaintenanceReportThreshold is the number of distinct reporters that flags a cache
+const needsMaintenanceReportThreshold = 3
+
+//visitCooldownSeconds is the minimum time between two check-ins of the same user on a cache
+const visitCooldownSeconds = 3600
+
+//autoArchiveHighSeverityThreshold is the number of open high severity reports a cache survives before it is archived
+const autoArchiveHighSeverityThreshold = 3
+
+//reportsEnabledKey is the world state key of the global reporting flag
+const reportsEnabledKey = "config_reportsEnabled"
+
+//visitorIndexName is the composite key namespace that indexes the visits by user and cache
+const visitorIndexName = "visitor~userid~cacheid"
+
+//verifiedVisitorAttribute is the enrollment certificate attribute that marks a verified caller
+const verifiedVisitorAttribute = "geocache.verified"
+
+//maxCoordRangeSpan is the widest allowed coordinate range on either axis
+const maxCoordRangeSpan = 100
+
+//obfuscationGridSize is the cell size the ranges of unpublished caches are snapped to in public reads
+const obfuscationGridSize = 50
+
+//availableFunctions lists the exported transactions of the contract, keep it in sync when methods are added
+var availableFunctions = []string{
+	"AddCachePhoto",
+	"AddPrerequisite",
+	"AddReview",
+	"AddVisitorGeo",
+	"AddVisitorToGeoCache",
+	"ArchiveGeoCache",
+	"ArchiveStaleReports",
+	"AssignReport",
+	"AuditLedgerSchema",
+	"AwardFavoritePoint",
+	"BlockUser",
+	"CreateGeoCache",
+	"CreateGeoCacheGeo",
+	"CreateGeoCachesBatch",
+	"DedupVisitors",
+	"DeleteGeoCache",
+	"DeleteReport",
+	"ExportAllGeoCachesGPX",
+	"ExportGeoCacheGPX",
+	"FindNearestCache",
+	"GeoCacheExists",
+	"GetAllGeoCaches",
+	"GetAuditTrail",
+	"GetAvailableFunctions",
+	"GetAverageRating",
+	"GetAverageTimeToFirstVisit",
+	"GetCachePhotos",
+	"GetCacheSpread",
+	"GetCachesByReportCount",
+	"GetCachesVisitedByUser",
+	"GetCachesWithMissionTrackables",
+	"GetGeoCacheGeoJSON",
+	"GetGeoCacheHistory",
+	"GetGeoCacheSummary",
+	"GetGeoCachesInBoxCreatedBetween",
+	"GetGeoCachesIntersecting",
+	"GetGeoCachesWithPagination",
+	"GetHint",
+	"GetLastModifier",
+	"GetLogbook",
+	"GetMaintenanceLog",
+	"GetMostPopularCaches",
+	"GetNearestOwnedCache",
+	"GetOwnedUnvisitedByOwner",
+	"GetOwnerResponsivenessRanking",
+	"GetRecentStatusChanges",
+	"GetRepeatVisitorCount",
+	"GetReports",
+	"GetReportsByNotifier",
+	"GetSeverityDistribution",
+	"GetTagCounts",
+	"GetTopRatedCaches",
+	"GetTrackableOwnershipChain",
+	"GetUnvisitedCaches",
+	"GetUserAverageFoundDifficulty",
+	"GetUserFindStreak",
+	"GetUserHeldTrackable",
+	"GetUserProfile",
+	"GetVisitorCount",
+	"GetVisitors",
+	"HasUserVisited",
+	"LogMaintenance",
+	"MidpointBetweenCaches",
+	"MigrateVisitors",
+	"PublishGeoCache",
+	"QueryGeoCachesByOwner",
+	"QueryGeoCachesInBox",
+	"ReadGeoCache",
+	"ReadGeoCachePublic",
+	"RefreshPopularityScore",
+	"RegisterUser",
+	"ReportGeoCache",
+	"ResolveReport",
+	"SearchReports",
+	"SetGeoCacheSize",
+	"SetGeoCacheStatus",
+	"SetReportsEnabled",
+	"SignLogbook",
+	"SwitchTrackable",
+	"TransferAllCaches",
+	"TransferOwnership",
+	"UnblockUser",
+	"UnpublishGeoCache",
+	"UpdateCoordGeoCache",
+	"UpdateGeoCache",
+	"UpdateRatings",
+	"UpdateTrackableValue",
+	"VerifyTrackableUniqueness",
+}
+
+//checks that the calling client belongs to the admin organization
+func requireAdmin(ctx contractapi.TransactionContextInterface) error {
+	mspId, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Could not read the client identity. %s", err)
+	}
+
+	if mspId != adminMSPID {
+		return fmt.Errorf("Only the admin organization can call this function!")
+	}
+
+	return nil
+}
+
+//returns the deterministic transaction time in unix seconds, never the wall clock
+func txTime(ctx contractapi.TransactionContextInterface) (int64, error) {
+	t, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("Could not read the transaction timestamp. %s", err)
+	}
+
+	return t.Seconds, nil
+}
+
+//formats a unix second timestamp the way it is stored on the ledger
+func formatTimestamp(seconds int64) string {
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+//appends an entry about the current modification to the cache's access log
+func recordAccess(ctx contractapi.TransactionContextInterface, geoCache *GeoCache, operation string) error {
+	clientId, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("Could not read the client identity. %s", err)
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry := new(AccessEntry)
+	entry.ClientId = clientId
+	entry.Operation = operation
+	entry.Timestamp = formatTimestamp(seconds)
+
+	geoCache.AccessLog = append(geoCache.AccessLog, *entry)
+	//every modification also bumps the last modified timestamp and the version of the cache
+	geoCache.UpdatedAt = entry.Timestamp
+	geoCache.Version++
+
+	return nil
+}
+
+//emitEvent marshals the payload and sets it as the chaincode event of the transaction
+func emitEvent(ctx contractapi.TransactionContextInterface, name string, v interface{}) error {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("Could not marshal the event payload. %s", err)
+	}
+
+	return ctx.GetStub().SetEvent(name, bytes)
+}
+
+//key of a user's account record in the world state
+func userAccountKey(userId string) string {
+	return "user_" + userId
+}
+
+//key of the trackable a user currently holds in the world state
+func heldTrackableKey(userId string) string {
+	return "held_" + userId
+}
+
+//the key prefixes of the world state records that are not caches; account, held trackable
+//and config values can unmarshal into a zero valued GeoCache, so an unmarshal check alone
+//does not keep them out of the cache scans
+var nonCacheKeyPrefixes = []string{"user_", "held_", "config_"}
+
+//reports whether the key belongs to one of the non-cache namespaces
+func isNonCacheKey(key string) bool {
+	for _, prefix := range nonCacheKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//reads the user's registered account from the world state, returns nil if the user never registered
+func readUserAccount(ctx contractapi.TransactionContextInterface, user User) (*UserAccount, error) {
+	data, err := ctx.GetStub().GetState(userAccountKey(user.Id))
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	}
+
+	if data == nil {
+		return nil, nil
+	}
+
+	account := new(UserAccount)
+
+	if err = json.Unmarshal(data, account); err != nil {
+		return nil, fmt.Errorf("Could not unmarshal world state data to type UserAccount")
+	}
+
+	return account, nil
+}
+
+//rejects the action when the user's registered account is blocked
+func requireNotBlocked(ctx contractapi.TransactionContextInterface, user User) error {
+	account, err := readUserAccount(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if account != nil && account.Blocked {
+		return fmt.Errorf("The user %s is blocked!", user.Name)
+	}
+
+	return nil
+}
+
+//stores the given blocked flag on the user's account, only for the admin organization
+func (c *GeoCacheContract) setUserBlocked(ctx contractapi.TransactionContextInterface, user User, blocked bool) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	account, err := readUserAccount(ctx, user)
+	if err != nil {
+		return err
+	} else if account == nil {
+		return fmt.Errorf("The user %s is not registered", user.Name)
+	}
+
+	account.Blocked = blocked
+
+	bytes, _ := json.Marshal(account)
+
+	return ctx.GetStub().PutState(userAccountKey(user.Id), bytes)
+}
+
+// BlockUser blocks a registered user from creating, visiting and reporting caches, only for the admin organization
+func (c *GeoCacheContract) BlockUser(ctx contractapi.TransactionContextInterface, user User) error {
+	return c.setUserBlocked(ctx, user, true)
+}
+
+// UnblockUser lifts the block from a registered user, only for the admin organization
+func (c *GeoCacheContract) UnblockUser(ctx contractapi.TransactionContextInterface, user User) error {
+	return c.setUserBlocked(ctx, user, false)
+}
+
+//reads the global reporting flag from the world state, a missing flag means enabled
+func reportsEnabled(ctx contractapi.TransactionContextInterface) (bool, error) {
+	bytes, err := ctx.GetStub().GetState(reportsEnabledKey)
+	if err != nil {
+		return false, fmt.Errorf("Could not read from world state. %s", err)
+	}
+
+	return bytes == nil || string(bytes) != "false", nil
+}
+
+// SetReportsEnabled turns report creation on or off globally, only for the admin organization
+func (c *GeoCacheContract) SetReportsEnabled(ctx contractapi.TransactionContextInterface, enabled bool) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	value := "true"
+	if !enabled {
+		value = "false"
+	}
+
+	return ctx.GetStub().PutState(reportsEnabledKey, []byte(value))
+}
+
+// RegisterUser stores a new account record for the user with the transaction time
+func (c *GeoCacheContract) RegisterUser(ctx contractapi.TransactionContextInterface, user User) error {
+	account, err := readUserAccount(ctx, user)
+	if err != nil {
+		return err
+	} else if account != nil {
+		return fmt.Errorf("The user %s is already registered", user.Name)
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	account = new(UserAccount)
+	account.Name = user.Name
+	account.RegisteredAt = seconds
+
+	bytes, _ := json.Marshal(account)
+
+	return ctx.GetStub().PutState(userAccountKey(user.Id), bytes)
+}
+
 // GeoCacheExists returns true when asset with given ID exists in world state
 func (c *GeoCacheContract) GeoCacheExists(ctx contractapi.TransactionContextInterface, geoCacheID string) (bool, error) {
 	data, err := ctx.GetStub().GetState(geoCacheID)
@@ -28,76 +383,2761 @@ func (c *GeoCacheContract) GeoCacheExists(ctx contractapi.TransactionContextInte
 		return false, err
 	}
 
-	return data != nil, nil
+	return data != nil, nil
+}
+
+//applies the traditional ROT13 obfuscation, used for storing hints
+func rot13(s string) string {
+	out := []rune(s)
+	for i, r := range out {
+		switch {
+		case r >= 'a' && r <= 'z':
+			out[i] = 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			out[i] = 'A' + (r-'A'+13)%26
+		}
+	}
+
+	return string(out)
+}
+
+//encodes a hint before it is stored on a cache
+func encodeHint(hint string) string {
+	return rot13(hint)
+}
+
+//decodes a hint stored on a cache
+func decodeHint(stored string) string {
+	return rot13(stored)
+}
+
+//hashCredential derives a PBKDF2-SHA256 digest from the user id and the salt,
+//this is what new owners are stored with
+func hashCredential(id string, salt string) string {
+	return hex.EncodeToString(pbkdf2.Key([]byte(id), []byte(salt), 4096, 32, sha256.New))
+}
+
+//verifyCredential checks the stored id against the PBKDF2 digest first and falls
+//back to the legacy stretched SHA1, so owners stored before the migration can
+//still authenticate; the salt always comes from the stored record, a salt supplied
+//by the client plays no part in the check
+func verifyCredential(storedId string, id string, salt string) bool {
+	return storedId == hashCredential(id, salt) || storedId == myHash(id+salt)
+}
+
+//returns a stretched hash from a given password
+func myHash(s string) string {
+	n := 1
+	for n < 100 {
+		h := sha1.New()
+		h.Write([]byte(s))
+		bs := h.Sum(nil)
+		s = string(bs)
+		n++
+	}
+
+	return hex.EncodeToString([]byte(s))
+}
+
+//returns a random string (usually for creating a salt)
+func generateRandomString() string {
+	rand.Seed(time.Now().UnixNano())
+	var letterRunes = []rune("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	salt := make([]rune, 8)
+	for i := range salt {
+		salt[i] = letterRunes[rand.Intn(len(letterRunes))]
+	}
+
+	return string(salt)
+}
+
+//checks that the coordinate is inside the range, the boundaries included
+func isInRange(coord int, r [2]int) bool {
+	return coord >= r[0] && coord <= r[1]
+}
+
+//checks that a photo hash looks like a hex digest
+func isHexString(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') && (r < 'A' || r > 'F') {
+			return false
+		}
+	}
+
+	return true
+}
+
+//checks that the container size is one of the allowed classifications
+func validateSize(size string) error {
+	switch size {
+	case "", sizeMicro, sizeSmall, sizeRegular, sizeLarge, sizeOther:
+		return nil
+	}
+
+	return fmt.Errorf("The size %s is not valid!", size)
+}
+
+//checks that a difficulty or terrain rating is on the standard 1-5 scale
+func validateRating(value int, name string) error {
+	if value < 1 || value > 5 {
+		return fmt.Errorf("The %s must be between 1 and 5!", name)
+	}
+
+	return nil
+}
+
+//checks that the user appears in either visitor list of the cache
+func hasVisited(geoCache *GeoCache, user User) bool {
+	for _, visitor := range geoCache.Visitors {
+		if visitor.Id == user.Id {
+			return true
+		}
+	}
+
+	for _, visit := range geoCache.VisitLog {
+		if visit.User.Id == user.Id {
+			return true
+		}
+	}
+
+	return false
+}
+
+//returns the great-circle distance of two GPS points in meters
+func haversineMeters(latA float64, lonA float64, latB float64, lonB float64) float64 {
+	const earthRadiusMeters = 6371000.0
+
+	phiA := latA * math.Pi / 180
+	phiB := latB * math.Pi / 180
+	deltaPhi := (latB - latA) * math.Pi / 180
+	deltaLambda := (lonB - lonA) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phiA)*math.Cos(phiB)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+
+	return earthRadiusMeters * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+//checks that a GPS position and find radius describe a valid place on the globe
+func validateGeoPosition(latitude float64, longitude float64, radiusMeters float64) error {
+	if latitude < -90 || latitude > 90 {
+		return fmt.Errorf("The latitude must be between -90 and 90!")
+	}
+
+	if longitude < -180 || longitude > 180 {
+		return fmt.Errorf("The longitude must be between -180 and 180!")
+	}
+
+	if radiusMeters <= 0 {
+		return fmt.Errorf("The radius must be positive!")
+	}
+
+	return nil
+}
+
+//mirrors the coordinate range bounds into the scalar query fields of the cache
+func syncRangeBounds(geoCache *GeoCache) {
+	geoCache.MinX = geoCache.XcoordRange[0]
+	geoCache.MaxX = geoCache.XcoordRange[1]
+	geoCache.MinY = geoCache.YcoordRange[0]
+	geoCache.MaxY = geoCache.YcoordRange[1]
+}
+
+//checks that neither coordinate range is wider than the allowed maximum span
+func validateCoordSpan(newXcoordRange [2]int, newYcoordRange [2]int) error {
+	if newXcoordRange[1]-newXcoordRange[0] > maxCoordRangeSpan {
+		return fmt.Errorf("The X coordinate range is wider than the allowed maximum!")
+	}
+
+	if newYcoordRange[1]-newYcoordRange[0] > maxCoordRangeSpan {
+		return fmt.Errorf("The Y coordinate range is wider than the allowed maximum!")
+	}
+
+	return nil
+}
+
+// CreateGeoCache creates a new instance of GeoCache and returns its generated trackable,
+// so the caller can immediately reference it for switching
+func (c *GeoCacheContract) CreateGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheID string, name string, description string, newXcoordRange [2]int, newYcoordRange [2]int, difficulty int, terrain int, size string, trackableValue string, trackableMission string, visitCode string, hint string) (*Trackable, error) {
+	if err := validateCoordSpan(newXcoordRange, newYcoordRange); err != nil {
+		return nil, err
+	}
+
+	if err := validateRating(difficulty, "difficulty"); err != nil {
+		return nil, err
+	}
+
+	if err := validateRating(terrain, "terrain"); err != nil {
+		return nil, err
+	}
+
+	if err := validateSize(size); err != nil {
+		return nil, err
+	}
+
+	//only the location and rating fields differ between the constructors, the rest is shared
+	geoCache := new(GeoCache)
+	//the key is stored on the object too, so a read back cache can be correlated with its key
+	geoCache.Id = geoCacheID
+	geoCache.Name = name
+	geoCache.Description = description
+	geoCache.XcoordRange = newXcoordRange
+	geoCache.YcoordRange = newYcoordRange
+	syncRangeBounds(geoCache)
+	geoCache.Difficulty = difficulty
+	geoCache.Terrain = terrain
+	geoCache.Size = size
+
+	return c.finishGeoCacheCreation(ctx, user, geoCache, trackableValue, trackableMission, visitCode, hint, "CreateGeoCache")
+}
+
+//finishGeoCacheCreation applies the location independent creation rules shared by the
+//constructors and persists the cache; the caller validates and fills the location fields
+//along with the id, the name and the description before calling it
+func (c *GeoCacheContract) finishGeoCacheCreation(ctx contractapi.TransactionContextInterface, user User, geoCache *GeoCache, trackableValue string, trackableMission string, visitCode string, hint string, operation string) (*Trackable, error) {
+	//an empty id would end up as the state key, an empty name makes the cache unidentifiable
+	if geoCache.Id == "" {
+		return nil, fmt.Errorf("The cache id must not be empty!")
+	}
+
+	if geoCache.Name == "" {
+		return nil, fmt.Errorf("The cache name must not be empty!")
+	}
+
+	//the public description must not leak the hint verbatim
+	if hint != "" && geoCache.Description == hint {
+		return nil, fmt.Errorf("The description must not duplicate the hint!")
+	}
+
+	//this read also protects against two concurrent creations of the same id: it lands
+	//the key in the read set, so when another transaction commits the key first the peer
+	//rejects this one on MVCC validation instead of silently overwriting the cache
+	exists, err := c.GeoCacheExists(ctx, geoCache.Id)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	} else if exists {
+		return nil, fmt.Errorf("The asset %s already exists", geoCache.Id)
+	}
+
+	//if the user has a registered account, it must be older than the configured minimum age
+	account, err := readUserAccount(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if account != nil {
+		if account.Blocked {
+			return nil, fmt.Errorf("The user %s is blocked!", user.Name)
+		}
+
+		seconds, err := txTime(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if seconds-account.RegisteredAt < minAccountAgeSeconds {
+			return nil, fmt.Errorf("The account is too new to create a cache!")
+		}
+	}
+
+	//an owner can only hold a limited number of caches
+	ownedCount, err := countCachesOwnedBy(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if ownedCount >= maxCachesPerOwner {
+		return nil, fmt.Errorf("The owner %s already has the maximum number of caches!", user.Name)
+	}
+
+	createSeconds, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	geoCache.CreatedAt = formatTimestamp(createSeconds)
+	geoCache.Owner = user
+	geoCache.Owner.Salt = generateRandomString()
+	geoCache.Owner.Id = hashCredential(user.Id, geoCache.Owner.Salt)
+	geoCache.Reports = []Report{}
+	geoCache.Visitors = []User{}
+	//a new cache starts its lifecycle as active
+	geoCache.Status = statusActive
+
+	//if a visit code is given, only its hash is stored, an empty code means an open cache
+	if visitCode != "" {
+		geoCache.VisitCode = myHash(visitCode)
+	}
+
+	//the hint is stored in an obfuscated form
+	if hint != "" {
+		geoCache.Hint = encodeHint(hint)
+	}
+
+	//create a trackable
+	trackable := new(Trackable)
+	//with a new random id, and the give value
+	trackable.Id = generateRandomString()
+	trackable.Value = trackableValue
+	trackable.Mission = trackableMission
+
+	geoCache.Trackable = *trackable
+
+	if err = recordAccess(ctx, geoCache, operation); err != nil {
+		return nil, err
+	}
+
+	bytes, _ := json.Marshal(geoCache)
+
+	if err = ctx.GetStub().PutState(geoCache.Id, bytes); err != nil {
+		return nil, err
+	}
+
+	//notify the listening clients about the new cache, without the salt
+	eventCache := *geoCache
+	eventCache.Owner.Salt = ""
+
+	if err = emitEvent(ctx, "GeoCacheCreated", eventCache); err != nil {
+		return nil, err
+	}
+
+	//hand the generated trackable back so the client can reference it right away
+	return &geoCache.Trackable, nil
+}
+
+// CreateGeoCacheGeo creates a new GPS based instance of GeoCache and returns its generated trackable,
+// the integer range based CreateGeoCache stays available for the legacy callers
+func (c *GeoCacheContract) CreateGeoCacheGeo(ctx contractapi.TransactionContextInterface, user User, geoCacheID string, name string, description string, latitude float64, longitude float64, radiusMeters float64, trackableValue string, trackableMission string, visitCode string, hint string) (*Trackable, error) {
+	if err := validateGeoPosition(latitude, longitude, radiusMeters); err != nil {
+		return nil, err
+	}
+
+	//only the location fields differ between the constructors, the rest is shared
+	geoCache := new(GeoCache)
+	//the key is stored on the object too, so a read back cache can be correlated with its key
+	geoCache.Id = geoCacheID
+	geoCache.Name = name
+	geoCache.Description = description
+	geoCache.Latitude = latitude
+	geoCache.Longitude = longitude
+	geoCache.RadiusMeters = radiusMeters
+
+	return c.finishGeoCacheCreation(ctx, user, geoCache, trackableValue, trackableMission, visitCode, hint, "CreateGeoCacheGeo")
+}
+
+// CreateGeoCachesBatch creates several caches owned by the same user in one transaction and
+// returns the ids it created; the batch fails fast, the first invalid or duplicated input aborts
+// the whole transaction unless the input is marked SkipIfExists, because Fabric discards every
+// write of a failed transaction there is no partial commit
+func (c *GeoCacheContract) CreateGeoCachesBatch(ctx contractapi.TransactionContextInterface, user User, caches []GeoCacheInput) ([]string, error) {
+	if len(caches) == 0 {
+		return nil, fmt.Errorf("The batch must not be empty!")
+	}
+
+	createdIds := []string{}
+	//writes of this transaction are not visible through GetState yet, so the ids
+	//created by the batch itself are tracked locally to catch duplicates within it
+	seenIds := make(map[string]bool)
+
+	//the cap check inside CreateGeoCache only sees the committed caches, so the
+	//ones created by the batch itself have to be counted here as well
+	committedOwned, err := countCachesOwnedBy(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, input := range caches {
+		if seenIds[input.Id] {
+			if input.SkipIfExists {
+				continue
+			}
+
+			return nil, fmt.Errorf("The asset %s already exists", input.Id)
+		}
+
+		if input.SkipIfExists {
+			exists, err := c.GeoCacheExists(ctx, input.Id)
+			if err != nil {
+				return nil, fmt.Errorf("Could not read from world state. %s", err)
+			} else if exists {
+				continue
+			}
+		}
+
+		if committedOwned+len(createdIds) >= maxCachesPerOwner {
+			return nil, fmt.Errorf("The owner %s already has the maximum number of caches!", user.Name)
+		}
+
+		_, err := c.CreateGeoCache(ctx, user, input.Id, input.Name, input.Description, input.XcoordRange, input.YcoordRange, input.Difficulty, input.Terrain, input.Size, input.TrackableValue, input.TrackableMission, input.VisitCode, input.Hint)
+		if err != nil {
+			return nil, err
+		}
+
+		seenIds[input.Id] = true
+		createdIds = append(createdIds, input.Id)
+	}
+
+	return createdIds, nil
+}
+
+// AddVisitorGeo records a visit of a GPS based cache when the visitor is within its find radius
+func (c *GeoCacheContract) AddVisitorGeo(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, latitude float64, longitude float64, visitCode string) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//a disabled or archived cache can not be visited, the empty legacy status counts as active
+	if geoCache.Status == statusDisabled || geoCache.Status == statusArchived {
+		return fmt.Errorf("The cache %s is not active!", geoCacheId)
+	}
+
+	//only a cache created with a find radius can be visited by GPS position
+	if geoCache.RadiusMeters <= 0 {
+		return fmt.Errorf("The cache %s has no find radius!", geoCacheId)
+	}
+
+	//the visitor has to stand within the find radius of the cache
+	if haversineMeters(latitude, longitude, geoCache.Latitude, geoCache.Longitude) > geoCache.RadiusMeters {
+		return fmt.Errorf("You are not within the find radius of the cache!")
+	}
+
+	//if the cache has a visit code, the given code's hash must match the stored one
+	if geoCache.VisitCode != "" && geoCache.VisitCode != myHash(visitCode) {
+		return fmt.Errorf("The given visit code is not valid!")
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	//a GPS visit follows the same re-check in rules as a range visit: an automated
+	//re-check in within the cooldown is rejected, based on the user's last logged visit
+	for i := len(geoCache.VisitLog) - 1; i >= 0; i-- {
+		visit := geoCache.VisitLog[i]
+		if visit.User.Id != user.Id {
+			continue
+		}
+
+		lastVisit, err := time.Parse(time.RFC3339, visit.Timestamp)
+		if err == nil && seconds-lastVisit.Unix() < visitCooldownSeconds {
+			return fmt.Errorf("The user %s has to wait before checking in again!", user.Name)
+		}
+		break
+	}
+
+	//the visitors list stays unique, a re-check in only extends the visit log
+	alreadyVisitor := false
+	for _, visitor := range geoCache.Visitors {
+		if visitor.Id == user.Id {
+			alreadyVisitor = true
+			break
+		}
+	}
+
+	if !alreadyVisitor {
+		geoCache.Visitors = append(geoCache.Visitors, user)
+	}
+
+	//record the visit with the transaction time as well
+	visit := new(Visit)
+	visit.User = user
+	visit.Timestamp = formatTimestamp(seconds)
+	geoCache.VisitLog = append(geoCache.VisitLog, *visit)
+
+	if err = recordAccess(ctx, geoCache, "AddVisitorGeo"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	if err = ctx.GetStub().PutState(geoCacheId, newBytes); err != nil {
+		return err
+	}
+
+	//maintain the visitor index so the visited caches of a user can be listed without a full scan
+	indexKey, err := ctx.GetStub().CreateCompositeKey(visitorIndexName, []string{user.Id, geoCacheId})
+	if err != nil {
+		return fmt.Errorf("Could not create the composite key. %s", err)
+	}
+
+	if err = ctx.GetStub().PutState(indexKey, []byte{0x00}); err != nil {
+		return err
+	}
+
+	//the find feed event only fires once the visit is stored
+	event := new(VisitorAddedEvent)
+	event.CacheId = geoCacheId
+	event.VisitorName = user.Name
+
+	return emitEvent(ctx, "VisitorAdded", *event)
+}
+
+// ReadGeoCache retrieves an instance of GeoCache from the world state
+func (c *GeoCacheContract) ReadGeoCache(ctx contractapi.TransactionContextInterface, geoCacheId string) (*GeoCache, error) {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return nil, fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	return geoCache, nil
+}
+
+// UpdateGeoCache retrieves an instance of GeoCache from the world state and updates its value;
+// a positive expectedVersion makes the update optimistic, it is rejected when the stored
+// version differs, a non positive value skips the check for the legacy callers
+func (c *GeoCacheContract) UpdateGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, newName string, newDescription string, expectedVersion int) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//if the user is not the owner, throw an error
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can update a cache!")
+	}
+
+	//the public description must not leak the hint verbatim
+	if geoCache.Hint != "" && newDescription == decodeHint(geoCache.Hint) {
+		return fmt.Errorf("The description must not duplicate the hint!")
+	}
+
+	//the write is rejected when someone else has modified the cache since the client read it
+	if expectedVersion > 0 && geoCache.Version != expectedVersion {
+		return fmt.Errorf("The cache %s was modified concurrently, expected version %d but found %d!", geoCacheId, expectedVersion, geoCache.Version)
+	}
+
+	geoCache.Name = newName
+	geoCache.Description = newDescription
+
+	if err = recordAccess(ctx, geoCache, "UpdateGeoCache"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// UpdateGeoCache retrieves an instance of GeoCache from the world state and updates its value
+func (c *GeoCacheContract) AddVisitorToGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, Xcoord int, Ycoord int, visitCode string) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//a disabled or archived cache can not be visited, the empty legacy status counts as active
+	if geoCache.Status == statusDisabled || geoCache.Status == statusArchived {
+		return fmt.Errorf("The cache %s is not active!", geoCacheId)
+	}
+
+	//if the user's coordinates not in the cache's range, throw an error
+	if !isInRange(Xcoord, geoCache.XcoordRange) || !isInRange(Ycoord, geoCache.YcoordRange) {
+		return fmt.Errorf("You are not in the cache's location range!")
+	}
+
+	//if the cache has a visit code, the given code's hash must match the stored one
+	if geoCache.VisitCode != "" && geoCache.VisitCode != myHash(visitCode) {
+		return fmt.Errorf("The given visit code is not valid!")
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	//an automated re-check in within the cooldown is rejected, based on the user's last logged visit
+	for i := len(geoCache.VisitLog) - 1; i >= 0; i-- {
+		visit := geoCache.VisitLog[i]
+		if visit.User.Id != user.Id {
+			continue
+		}
+
+		lastVisit, err := time.Parse(time.RFC3339, visit.Timestamp)
+		if err == nil && seconds-lastVisit.Unix() < visitCooldownSeconds {
+			return fmt.Errorf("The user %s has to wait before checking in again!", user.Name)
+		}
+		break
+	}
+
+	//the visitors list stays unique, a re-check in only extends the visit log
+	alreadyVisitor := false
+	for _, visitor := range geoCache.Visitors {
+		if visitor.Id == user.Id {
+			alreadyVisitor = true
+			break
+		}
+	}
+
+	if !alreadyVisitor {
+		geoCache.Visitors = append(geoCache.Visitors, user)
+	}
+
+	//when the caller's enrollment certificate carries the verified visitor attribute, the
+	//visit is also stored salted and hashed like an owner, so it can be proven later; the
+	//plain visitors list stays as it is for backward compatibility
+	verifiedValue, hasAttribute, err := ctx.GetClientIdentity().GetAttributeValue(verifiedVisitorAttribute)
+	if err != nil {
+		return fmt.Errorf("Could not read the client identity. %s", err)
+	}
+
+	if hasAttribute && verifiedValue == "true" {
+		verified := user
+		verified.Salt = generateRandomString()
+		verified.Id = myHash(user.Id + verified.Salt)
+		geoCache.VerifiedVisitors = append(geoCache.VerifiedVisitors, verified)
+	}
+
+	//record the visit with the transaction time as well
+	visit := new(Visit)
+	visit.User = user
+	visit.Xcoord = Xcoord
+	visit.Ycoord = Ycoord
+	visit.Timestamp = formatTimestamp(seconds)
+	geoCache.VisitLog = append(geoCache.VisitLog, *visit)
+
+	if err = recordAccess(ctx, geoCache, "AddVisitorToGeoCache"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	if err = ctx.GetStub().PutState(geoCacheId, newBytes); err != nil {
+		return err
+	}
+
+	//maintain the visitor index so the visited caches of a user can be listed without a full scan
+	indexKey, err := ctx.GetStub().CreateCompositeKey(visitorIndexName, []string{user.Id, geoCacheId})
+	if err != nil {
+		return fmt.Errorf("Could not create the composite key. %s", err)
+	}
+
+	if err = ctx.GetStub().PutState(indexKey, []byte{0x00}); err != nil {
+		return err
+	}
+
+	//the find feed event only fires once the visit is stored
+	event := new(VisitorAddedEvent)
+	event.CacheId = geoCacheId
+	event.VisitorName = user.Name
+	event.Xcoord = Xcoord
+	event.Ycoord = Ycoord
+
+	return emitEvent(ctx, "VisitorAdded", *event)
+}
+
+//switches the given cache's and user's trackables
+func (c *GeoCacheContract) SwitchTrackable(ctx contractapi.TransactionContextInterface, user User, trackable Trackable, geoCacheId string) (*Trackable, error) {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return nil, fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return nil, err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//only a logged visitor of the cache may swap its trackable, so a drive-by caller can not loot it
+	if !hasVisited(geoCache, user) {
+		return nil, fmt.Errorf("Only a visitor of the cache can switch its trackable!")
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	//record who dropped the trackable into the cache and when
+	move := new(TrackableMove)
+	//the trackable travels from the cache of its last move
+	if len(trackable.History) > 0 {
+		move.FromCacheId = trackable.History[len(trackable.History)-1].ToCacheId
+	}
+	move.ToCacheId = geoCacheId
+
+	//the travelled distance grows by the euclidean distance between the two range centers
+	if move.FromCacheId != "" {
+		fromBytes, _ := ctx.GetStub().GetState(move.FromCacheId)
+		fromCache := new(GeoCache)
+		if err := json.Unmarshal(fromBytes, fromCache); err == nil {
+			dx := float64(geoCache.XcoordRange[0]+geoCache.XcoordRange[1]-fromCache.XcoordRange[0]-fromCache.XcoordRange[1]) / 2
+			dy := float64(geoCache.YcoordRange[0]+geoCache.YcoordRange[1]-fromCache.YcoordRange[0]-fromCache.YcoordRange[1]) / 2
+			trackable.DistanceTraveled += int(math.Round(math.Hypot(dx, dy)))
+		}
+	}
+	move.Timestamp = formatTimestamp(seconds)
+	move.MovedBy = user
+	trackable.History = append(trackable.History, *move)
+
+	cacheTrackable := geoCache.Trackable
+	geoCache.Trackable = trackable
+
+	if err = recordAccess(ctx, geoCache, "SwitchTrackable"); err != nil {
+		return nil, err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	if err = ctx.GetStub().PutState(geoCacheId, newBytes); err != nil {
+		return nil, err
+	}
+
+	//the user walks away with the cache's old trackable
+	heldBytes, _ := json.Marshal(cacheTrackable)
+
+	return &cacheTrackable, ctx.GetStub().PutState(heldTrackableKey(user.Id), heldBytes)
+}
+
+// UpdateTrackableValue edits the value of the trackable a cache currently holds,
+// without the full swap of SwitchTrackable, only for a logged visitor of the cache
+func (c *GeoCacheContract) UpdateTrackableValue(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, newValue string) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//only a logged visitor of the cache may edit its trackable, so a drive-by caller can not deface it
+	if !hasVisited(geoCache, user) {
+		return fmt.Errorf("Only a visitor of the cache can update its trackable!")
+	}
+
+	geoCache.Trackable.Value = newValue
+
+	if err = recordAccess(ctx, geoCache, "UpdateTrackableValue"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// UpdateRatings sets the difficulty and terrain ratings of a cache, only for its owner
+func (c *GeoCacheContract) UpdateRatings(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, difficulty int, terrain int) error {
+	if err := validateRating(difficulty, "difficulty"); err != nil {
+		return err
+	}
+
+	if err := validateRating(terrain, "terrain"); err != nil {
+		return err
+	}
+
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//if the user is not the owner, throw an error
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can update the ratings!")
+	}
+
+	geoCache.Difficulty = difficulty
+	geoCache.Terrain = terrain
+
+	if err = recordAccess(ctx, geoCache, "UpdateRatings"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// UpdateGeoCache retrieves two list of new koordinates of GeoCache from the world state and updates its value
+func (c *GeoCacheContract) UpdateCoordGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, newXcoordRange [2]int, newYcoordRange [2]int) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//if the user is not the owner, throw an error
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can update a cache!")
+	}
+
+	if err := validateCoordSpan(newXcoordRange, newYcoordRange); err != nil {
+		return err
+	}
+
+	geoCache.XcoordRange = newXcoordRange
+	geoCache.YcoordRange = newYcoordRange
+	syncRangeBounds(geoCache)
+
+	if err = recordAccess(ctx, geoCache, "UpdateCoordGeoCache"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// DeleteGeoCache deletes an instance of GeoCache from the world state; this is destructive,
+// the record and any trackable sitting in the cache are gone for good, prefer ArchiveGeoCache
+// which keeps the cache readable while taking it out of play; a cache still holding a
+// trackable is only deleted when force is set
+func (c *GeoCacheContract) DeleteGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, force bool) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//if the user is not the owner, throw an error
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can update a cache!")
+	}
+
+	//deleting the cache destroys the trackable inside it and orphans its mission
+	if geoCache.Trackable.Id != "" && !force {
+		return fmt.Errorf("The cache %s still holds a trackable!", geoCacheId)
+	}
+
+	return ctx.GetStub().DelState(geoCacheId)
+}
+
+// ArchiveGeoCache is the soft delete of a cache, it keeps the record in the world state
+// but moves it to the archived status, so visits and reports are rejected from then on
+func (c *GeoCacheContract) ArchiveGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//if the user is not the owner, throw an error
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can archive a cache!")
+	}
+
+	geoCache.Status = statusArchived
+
+	if err = recordAccess(ctx, geoCache, "ArchiveGeoCache"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+//ReportGeoCache make a report for a cache
+func (c *GeoCacheContract) ReportGeoCache(ctx contractapi.TransactionContextInterface, user User, message string, geoCacheId string, visitRef string, severity string) error {
+	//a report without a message is of no use to the owner
+	if message == "" {
+		return fmt.Errorf("The report message must not be empty!")
+	}
+
+	//an empty severity is allowed for the legacy callers
+	if severity != "" && severity != severityLow && severity != severityMedium && severity != severityHigh {
+		return fmt.Errorf("The severity %s is not valid!", severity)
+	}
+
+	enabled, err := reportsEnabled(ctx)
+	if err != nil {
+		return err
+	} else if !enabled {
+		return fmt.Errorf("Reporting is currently disabled!")
+	}
+
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//an archived cache is out of play, nobody maintains it anymore
+	if geoCache.Status == statusArchived {
+		return fmt.Errorf("The cache %s is archived!", geoCacheId)
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	//an optional visit reference must point at a real visit of the cache
+	if visitRef != "" {
+		found := false
+		for _, visit := range geoCache.VisitLog {
+			if visit.Timestamp == visitRef {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("The referenced visit does not exist!")
+		}
+	}
+
+	//create a report object and save to the cache's reports
+	report := new(Report)
+	report.Id = generateRandomString()
+	report.Message = message
+	report.Notifier = user
+	report.CreatedAt = seconds
+	report.VisitRef = visitRef
+	report.Severity = severity
+
+	geoCache.Reports = append(geoCache.Reports, *report)
+
+	//flag the cache for maintenance once enough distinct users reported it
+	notifiers := make(map[string]bool)
+	for _, r := range geoCache.Reports {
+		if !r.Archived {
+			notifiers[r.Notifier.Id] = true
+		}
+	}
+
+	if len(notifiers) >= needsMaintenanceReportThreshold {
+		geoCache.Status = statusNeedsMaintenance
+	}
+
+	//archive the cache in the same transaction once too many open high severity reports pile up
+	openHighSeverity := 0
+	for _, r := range geoCache.Reports {
+		if !r.Archived && r.Severity == severityHigh {
+			openHighSeverity++
+		}
+	}
+
+	autoArchived := false
+	if openHighSeverity > autoArchiveHighSeverityThreshold && geoCache.Status != statusArchived {
+		geoCache.Status = statusArchived
+		autoArchived = true
+	}
+
+	if err = recordAccess(ctx, geoCache, "ReportGeoCache"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	if err = ctx.GetStub().PutState(geoCacheId, newBytes); err != nil {
+		return err
+	}
+
+	//notify the listening clients only after the archive is stored
+	if autoArchived {
+		event := new(GeoCacheAutoArchivedEvent)
+		event.CacheId = geoCacheId
+		event.OpenHighSeverityReports = openHighSeverity
+
+		return emitEvent(ctx, "GeoCacheAutoArchived", *event)
+	}
+
+	return nil
+}
+
+//iterateValidCaches runs the callback for every world state value that can be read as a cache and skips the rest
+func iterateValidCaches(ctx contractapi.TransactionContextInterface, fn func(id string, geoCache *GeoCache) error) error {
+	iterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return fmt.Errorf("Could not read from world state. %s", err)
+		}
+
+		//skip the known non-cache namespaces, their values would unmarshal into empty caches
+		if isNonCacheKey(queryResponse.Key) {
+			continue
+		}
+
+		geoCache := new(GeoCache)
+
+		//skip the values that can not be read as a cache
+		if err = json.Unmarshal(queryResponse.Value, geoCache); err != nil {
+			continue
+		}
+
+		if err = fn(queryResponse.Key, geoCache); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCachesVisitedByUser returns the ids of the caches the user has visited, served from the visitor index
+func (c *GeoCacheContract) GetCachesVisitedByUser(ctx contractapi.TransactionContextInterface, user User) ([]string, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(visitorIndexName, []string{user.Id})
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	}
+	defer iterator.Close()
+
+	cacheIds := []string{}
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Could not read from world state. %s", err)
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("Could not split the composite key. %s", err)
+		}
+
+		//the second attribute of the index key is the cache id
+		if len(attributes) == 2 {
+			cacheIds = append(cacheIds, attributes[1])
+		}
+	}
+
+	return cacheIds, nil
+}
+
+// GetUnvisitedCaches returns every cache that has no visitors yet
+func (c *GeoCacheContract) GetUnvisitedCaches(ctx contractapi.TransactionContextInterface) ([]*GeoCache, error) {
+	unvisited := []*GeoCache{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//drafts are not part of the public listing
+		if !geoCache.Published {
+			return nil
+		}
+
+		if len(geoCache.Visitors) == 0 {
+			//the salt must not leak in a public listing
+			geoCache.Owner.Salt = ""
+			unvisited = append(unvisited, geoCache)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unvisited, nil
+}
+
+// MidpointBetweenCaches returns the great-circle midpoint of two GPS-enabled caches
+func (c *GeoCacheContract) MidpointBetweenCaches(ctx contractapi.TransactionContextInterface, cacheIdA string, cacheIdB string) (float64, float64, error) {
+	cacheA, err := c.ReadGeoCache(ctx, cacheIdA)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	cacheB, err := c.ReadGeoCache(ctx, cacheIdB)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if cacheA.Latitude == 0 && cacheA.Longitude == 0 {
+		return 0, 0, fmt.Errorf("The cache %s has no GPS coordinates!", cacheIdA)
+	}
+
+	if cacheB.Latitude == 0 && cacheB.Longitude == 0 {
+		return 0, 0, fmt.Errorf("The cache %s has no GPS coordinates!", cacheIdB)
+	}
+
+	//standard spherical midpoint formula
+	latA := cacheA.Latitude * math.Pi / 180
+	lonA := cacheA.Longitude * math.Pi / 180
+	latB := cacheB.Latitude * math.Pi / 180
+	lonB := cacheB.Longitude * math.Pi / 180
+
+	bx := math.Cos(latB) * math.Cos(lonB-lonA)
+	by := math.Cos(latB) * math.Sin(lonB-lonA)
+
+	midLat := math.Atan2(math.Sin(latA)+math.Sin(latB), math.Sqrt((math.Cos(latA)+bx)*(math.Cos(latA)+bx)+by*by))
+	midLon := lonA + math.Atan2(by, math.Cos(latA)+bx)
+
+	return midLat * 180 / math.Pi, midLon * 180 / math.Pi, nil
+}
+
+// GetUserAverageFoundDifficulty returns the average difficulty rating of the caches the user has logged a find on
+func (c *GeoCacheContract) GetUserAverageFoundDifficulty(ctx contractapi.TransactionContextInterface, user User) (float64, error) {
+	sum := 0
+	count := 0
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		for _, visit := range geoCache.VisitLog {
+			if visit.User.Id == user.Id {
+				//unrated caches do not influence the average
+				if geoCache.Difficulty > 0 {
+					sum += geoCache.Difficulty
+					count++
+				}
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if count == 0 {
+		return 0, fmt.Errorf("The user %s has not found any rated caches!", user.Id)
+	}
+
+	return float64(sum) / float64(count), nil
+}
+
+// GetUserFindStreak returns the longest run of consecutive calendar days the user logged a find
+func (c *GeoCacheContract) GetUserFindStreak(ctx contractapi.TransactionContextInterface, user User) (int, error) {
+	//collect the distinct days the user logged a find on
+	daySet := make(map[string]bool)
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		for _, visit := range geoCache.VisitLog {
+			//a malformed stored timestamp is skipped instead of aborting the transaction
+			if visit.User.Id == user.Id && len(visit.Timestamp) >= 10 {
+				daySet[visit.Timestamp[:10]] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	days := []string{}
+	for day := range daySet {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	//walk the sorted days and count the longest consecutive run
+	longest := 0
+	current := 0
+	var previous time.Time
+	for _, day := range days {
+		parsed, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+
+		if current > 0 && parsed.Sub(previous) == 24*time.Hour {
+			current++
+		} else {
+			current = 1
+		}
+
+		if current > longest {
+			longest = current
+		}
+
+		previous = parsed
+	}
+
+	return longest, nil
+}
+
+// GetUserProfile aggregates the activity of a user across every cache in one scan
+func (c *GeoCacheContract) GetUserProfile(ctx contractapi.TransactionContextInterface, user User) (*UserProfile, error) {
+	profile := new(UserProfile)
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//the owner id is salted per cache, so it has to be rehashed for every candidate
+		if verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+			profile.OwnedCaches++
+		}
+
+		for _, visitor := range geoCache.Visitors {
+			if visitor.Id == user.Id {
+				profile.VisitedCaches++
+				break
+			}
+		}
+
+		//every check-in counts as a find, repeat visits included
+		for _, visit := range geoCache.VisitLog {
+			if visit.User.Id == user.Id {
+				profile.FoundCaches++
+			}
+		}
+
+		for _, report := range geoCache.Reports {
+			if report.Notifier.Id == user.Id {
+				profile.ReportsFiled++
+			}
+		}
+
+		for _, favoriter := range geoCache.FavoritedBy {
+			if favoriter.Id == user.Id {
+				profile.Favorites++
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// LogMaintenance appends a maintenance entry to the cache, only for the owner
+func (c *GeoCacheContract) LogMaintenance(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, note string) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can update a cache!")
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry := new(MaintenanceEntry)
+	entry.Note = note
+	//the maintainer is persisted with the entry, so a client supplied salt must not leak into it
+	user.Salt = ""
+	entry.Maintainer = user
+	entry.Timestamp = formatTimestamp(seconds)
+
+	geoCache.MaintenanceLog = append(geoCache.MaintenanceLog, *entry)
+
+	//a maintained cache does not need maintenance anymore
+	if geoCache.Status == statusNeedsMaintenance {
+		geoCache.Status = ""
+	}
+
+	if err = recordAccess(ctx, geoCache, "LogMaintenance"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// AddReview appends a star rating with a comment to the cache, only for its visitors
+func (c *GeoCacheContract) AddReview(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, stars int, comment string) error {
+	if stars < 1 || stars > 5 {
+		return fmt.Errorf("The stars must be between 1 and 5!")
+	}
+
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//only someone who found the cache can rate it
+	visited := false
+	for _, visitor := range geoCache.Visitors {
+		if visitor.Id == user.Id {
+			visited = true
+			break
+		}
+	}
+
+	if !visited {
+		return fmt.Errorf("Only a visitor can review a cache!")
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	review := new(Review)
+	review.Reviewer = user
+	review.Stars = stars
+	review.Comment = comment
+	review.Timestamp = formatTimestamp(seconds)
+
+	geoCache.Reviews = append(geoCache.Reviews, *review)
+
+	if err = recordAccess(ctx, geoCache, "AddReview"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// AddCachePhoto attaches the content hash of an off-chain photo to a cache, for the owner or a visitor
+func (c *GeoCacheContract) AddCachePhoto(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, photoHash string) error {
+	//the hash points into off-chain storage, so it has to look like a hex digest
+	if !isHexString(photoHash) {
+		return fmt.Errorf("The photo hash must be a hex string!")
+	}
+
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//only the owner or somebody who found the cache can attach a photo
+	if !hasVisited(geoCache, user) && !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner or a visitor can attach a photo!")
+	}
+
+	geoCache.PhotoHashes = append(geoCache.PhotoHashes, photoHash)
+
+	if err = recordAccess(ctx, geoCache, "AddCachePhoto"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// GetCachePhotos returns the photo content hashes attached to a cache
+func (c *GeoCacheContract) GetCachePhotos(ctx contractapi.TransactionContextInterface, geoCacheId string) ([]string, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return nil, err
+	}
+
+	return geoCache.PhotoHashes, nil
+}
+
+// SignLogbook appends a free-text note of a visitor to the logbook of a cache
+func (c *GeoCacheContract) SignLogbook(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, text string) error {
+	//a note without text is of no use in the logbook
+	if text == "" {
+		return fmt.Errorf("The logbook text must not be empty!")
+	}
+
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//only somebody who found the cache can sign its logbook
+	if !hasVisited(geoCache, user) {
+		return fmt.Errorf("Only a visitor can sign the logbook!")
+	}
+
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry := new(LogEntry)
+	entry.Author = user
+	entry.Text = text
+	entry.Timestamp = formatTimestamp(seconds)
+	geoCache.Logbook = append(geoCache.Logbook, *entry)
+
+	if err = recordAccess(ctx, geoCache, "SignLogbook"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// GetLogbook returns the free-text logbook entries of a cache
+func (c *GeoCacheContract) GetLogbook(ctx contractapi.TransactionContextInterface, geoCacheId string) ([]LogEntry, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return nil, err
+	}
+
+	return geoCache.Logbook, nil
+}
+
+// AwardFavoritePoint lets a visitor award one favorite point to a cache
+func (c *GeoCacheContract) AwardFavoritePoint(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	if err = requireNotBlocked(ctx, user); err != nil {
+		return err
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//only somebody who found the cache can award a point
+	if !hasVisited(geoCache, user) {
+		return fmt.Errorf("Only a visitor can award a favorite point!")
+	}
+
+	//a user can only award a single point per cache
+	for _, favoriter := range geoCache.FavoritedBy {
+		if favoriter.Id == user.Id {
+			return fmt.Errorf("The user %s has already awarded a favorite point!", user.Name)
+		}
+	}
+
+	geoCache.FavoritedBy = append(geoCache.FavoritedBy, user)
+	geoCache.FavoritePoints = len(geoCache.FavoritedBy)
+
+	if err = recordAccess(ctx, geoCache, "AwardFavoritePoint"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// GetAverageRating returns the average star rating of a cache
+func (c *GeoCacheContract) GetAverageRating(ctx contractapi.TransactionContextInterface, geoCacheId string) (float64, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(geoCache.Reviews) == 0 {
+		return 0, fmt.Errorf("The cache %s has no reviews!", geoCacheId)
+	}
+
+	total := 0
+	for _, review := range geoCache.Reviews {
+		total += review.Stars
+	}
+
+	return float64(total) / float64(len(geoCache.Reviews)), nil
+}
+
+// AddPrerequisite requires another cache to be found before this one, only for the owner;
+// a requirement that would make a cache depend on itself, directly or through a chain, is rejected
+func (c *GeoCacheContract) AddPrerequisite(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, prerequisiteId string) error {
+	if geoCacheId == prerequisiteId {
+		return fmt.Errorf("A cache can not require itself!")
+	}
+
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	exists, err = c.GeoCacheExists(ctx, prerequisiteId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", prerequisiteId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can add a prerequisite!")
+	}
+
+	//walk the prerequisite chain of the candidate, the new edge must not close a loop
+	visited := map[string]bool{}
+	pending := []string{prerequisiteId}
+	for len(pending) > 0 {
+		currentId := pending[0]
+		pending = pending[1:]
+
+		if currentId == geoCacheId {
+			return fmt.Errorf("The prerequisite would create a cycle!")
+		}
+
+		if visited[currentId] {
+			continue
+		}
+		visited[currentId] = true
+
+		currentBytes, err := ctx.GetStub().GetState(currentId)
+		if err != nil {
+			return fmt.Errorf("Could not read from world state. %s", err)
+		} else if currentBytes == nil {
+			continue
+		}
+
+		current := new(GeoCache)
+		if err = json.Unmarshal(currentBytes, current); err != nil {
+			continue
+		}
+
+		pending = append(pending, current.Prerequisites...)
+	}
+
+	geoCache.Prerequisites = append(geoCache.Prerequisites, prerequisiteId)
+
+	if err = recordAccess(ctx, geoCache, "AddPrerequisite"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// SetGeoCacheStatus moves a cache to another lifecycle state, only for the owner
+func (c *GeoCacheContract) SetGeoCacheStatus(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, status string) error {
+	if status != statusActive && status != statusDisabled && status != statusArchived && status != statusNeedsMaintenance {
+		return fmt.Errorf("The status %s is not valid!", status)
+	}
+
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can change the status of a cache!")
+	}
+
+	geoCache.Status = status
+
+	if err = recordAccess(ctx, geoCache, "SetGeoCacheStatus"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// SetGeoCacheSize sets the container size classification of a cache, only for its owner
+func (c *GeoCacheContract) SetGeoCacheSize(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, size string) error {
+	if err := validateSize(size); err != nil {
+		return err
+	}
+
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can set the size of a cache!")
+	}
+
+	geoCache.Size = size
+
+	if err = recordAccess(ctx, geoCache, "SetGeoCacheSize"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// GetRepeatVisitorCount returns how many distinct visitors logged more than one visit, only for the owner
+func (c *GeoCacheContract) GetRepeatVisitorCount(ctx contractapi.TransactionContextInterface, owner User, geoCacheId string) (int, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return 0, err
+	}
+
+	if !verifyCredential(geoCache.Owner.Id, owner.Id, geoCache.Owner.Salt) {
+		return 0, fmt.Errorf("Only the owner can view the visitor metrics!")
+	}
+
+	visits := make(map[string]int)
+	for _, visit := range geoCache.VisitLog {
+		visits[visit.User.Id]++
+	}
+
+	repeat := 0
+	for _, count := range visits {
+		if count > 1 {
+			repeat++
+		}
+	}
+
+	return repeat, nil
+}
+
+// HasUserVisited returns whether the user already appears among the cache's visitors;
+// visitors are stored with their raw id, so two users are the same when their Id matches
+func (c *GeoCacheContract) HasUserVisited(ctx contractapi.TransactionContextInterface, geoCacheId string, user User) (bool, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, visitor := range geoCache.Visitors {
+		if visitor.Id == user.Id {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetVisitorCount returns how many visitors a cache has without the full visitor list
+func (c *GeoCacheContract) GetVisitorCount(ctx contractapi.TransactionContextInterface, geoCacheId string) (int, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(geoCache.Visitors), nil
+}
+
+// GetMaintenanceLog returns the maintenance entries of a cache
+func (c *GeoCacheContract) GetMaintenanceLog(ctx contractapi.TransactionContextInterface, geoCacheId string) ([]MaintenanceEntry, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return nil, err
+	}
+
+	return geoCache.MaintenanceLog, nil
+}
+
+// GetAvailableFunctions returns the names of the exported transactions of the contract
+func (c *GeoCacheContract) GetAvailableFunctions(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	return availableFunctions, nil
+}
+
+// GetOwnedUnvisitedByOwner returns the user's own caches where they never logged a find
+func (c *GeoCacheContract) GetOwnedUnvisitedByOwner(ctx contractapi.TransactionContextInterface, user User) ([]*GeoCache, error) {
+	unvisited := []*GeoCache{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//the owner id is salted per cache, so it has to be rehashed for every candidate
+		if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+			return nil
+		}
+
+		for _, visit := range geoCache.VisitLog {
+			if visit.User.Id == user.Id {
+				return nil
+			}
+		}
+
+		unvisited = append(unvisited, geoCache)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unvisited, nil
+}
+
+// GetNearestOwnedCache returns the caller's cache whose midpoint lies closest to the given point
+func (c *GeoCacheContract) GetNearestOwnedCache(ctx contractapi.TransactionContextInterface, user User, x int, y int) (*GeoCache, error) {
+	var nearest *GeoCache
+	var nearestDistance float64
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//the owner id is salted per cache, so it has to be rehashed for every candidate
+		if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+			return nil
+		}
+
+		midX := float64(geoCache.XcoordRange[0]+geoCache.XcoordRange[1]) / 2
+		midY := float64(geoCache.YcoordRange[0]+geoCache.YcoordRange[1]) / 2
+		distance := math.Hypot(midX-float64(x), midY-float64(y))
+
+		if nearest == nil || distance < nearestDistance {
+			nearest = geoCache
+			nearestDistance = distance
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if nearest == nil {
+		return nil, fmt.Errorf("The user %s does not own any caches!", user.Name)
+	}
+
+	return nearest, nil
+}
+
+// FindNearestCache returns the cache whose range center is closest to the given point and the distance to it
+func (c *GeoCacheContract) FindNearestCache(ctx contractapi.TransactionContextInterface, Xcoord int, Ycoord int) (*GeoCache, float64, error) {
+	var nearest *GeoCache
+	var nearestDistance float64
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//drafts stay out of the search, repeated nearest queries would pinpoint their location
+		if !geoCache.Published {
+			return nil
+		}
+
+		midX := float64(geoCache.XcoordRange[0]+geoCache.XcoordRange[1]) / 2
+		midY := float64(geoCache.YcoordRange[0]+geoCache.YcoordRange[1]) / 2
+		distance := math.Hypot(midX-float64(Xcoord), midY-float64(Ycoord))
+
+		if nearest == nil || distance < nearestDistance {
+			nearest = geoCache
+			nearestDistance = distance
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if nearest == nil {
+		return nil, 0, fmt.Errorf("There are no caches in the world state!")
+	}
+
+	//the salt must not leak in a public listing
+	nearest.Owner.Salt = ""
+
+	return nearest, nearestDistance, nil
+}
+
+// GetUserHeldTrackable returns the trackable the user picked up but has not dropped yet
+func (c *GeoCacheContract) GetUserHeldTrackable(ctx contractapi.TransactionContextInterface, user User) (*Trackable, error) {
+	bytes, err := ctx.GetStub().GetState(heldTrackableKey(user.Id))
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	} else if bytes == nil {
+		return nil, fmt.Errorf("The user %s does not hold a trackable", user.Name)
+	}
+
+	trackable := new(Trackable)
+
+	err = json.Unmarshal(bytes, trackable)
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal world state data to type Trackable")
+	}
+
+	return trackable, nil
+}
+
+// DedupVisitors removes the duplicate visitor entries left behind by the legacy append bug, only for the owner
+func (c *GeoCacheContract) DedupVisitors(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) (int, error) {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return 0, fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return 0, fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return 0, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return 0, fmt.Errorf("Only the owner can update a cache!")
+	}
+
+	//keep the first occurrence of every visitor id
+	seen := make(map[string]bool)
+	deduped := []User{}
+	for _, visitor := range geoCache.Visitors {
+		if seen[visitor.Id] {
+			continue
+		}
+		seen[visitor.Id] = true
+		deduped = append(deduped, visitor)
+	}
+
+	removed := len(geoCache.Visitors) - len(deduped)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	geoCache.Visitors = deduped
+
+	if err = recordAccess(ctx, geoCache, "DedupVisitors"); err != nil {
+		return 0, err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return removed, ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+//snaps a coordinate range outwards to the coarse obfuscation grid
+func snapRangeToGrid(r [2]int) [2]int {
+	lower := int(math.Floor(float64(r[0])/obfuscationGridSize)) * obfuscationGridSize
+	upper := int(math.Ceil(float64(r[1])/obfuscationGridSize)) * obfuscationGridSize
+
+	return [2]int{lower, upper}
+}
+
+// ReadGeoCachePublic returns a cache for public consumption, the exact ranges of
+// unpublished caches are snapped to a coarse grid and the salt is never included
+func (c *GeoCacheContract) ReadGeoCachePublic(ctx contractapi.TransactionContextInterface, geoCacheId string) (*GeoCache, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !geoCache.Published {
+		geoCache.XcoordRange = snapRangeToGrid(geoCache.XcoordRange)
+		geoCache.YcoordRange = snapRangeToGrid(geoCache.YcoordRange)
+		syncRangeBounds(geoCache)
+	}
+
+	geoCache.Owner.Salt = ""
+
+	return geoCache, nil
+}
+
+//stores the given published flag on the cache, only for the owner
+func (c *GeoCacheContract) setPublished(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, published bool, operation string) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can update a cache!")
+	}
+
+	geoCache.Published = published
+
+	if err = recordAccess(ctx, geoCache, operation); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// PublishGeoCache makes a draft cache visible in the public listings, only for the owner
+func (c *GeoCacheContract) PublishGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) error {
+	return c.setPublished(ctx, user, geoCacheId, true, "PublishGeoCache")
+}
+
+// UnpublishGeoCache hides a cache from the public listings again, only for the owner
+func (c *GeoCacheContract) UnpublishGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) error {
+	return c.setPublished(ctx, user, geoCacheId, false, "UnpublishGeoCache")
+}
+
+// GetAllGeoCaches returns every cache in the world state without the owner salts
+func (c *GeoCacheContract) GetAllGeoCaches(ctx contractapi.TransactionContextInterface) ([]*GeoCache, error) {
+	caches := []*GeoCache{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//drafts are not part of the public listing
+		if !geoCache.Published {
+			return nil
+		}
+
+		//the salt must not leak in a public listing
+		geoCache.Owner.Salt = ""
+
+		caches = append(caches, geoCache)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return caches, nil
+}
+
+// GetGeoCachesWithPagination returns one page of the cache listing with a bookmark to the next
+// page; the raw range also holds drafts and non-cache records, so the listing keeps fetching
+// until it has a full page of caches or the range is exhausted, and FetchedRecordsCount counts
+// the returned caches rather than the raw records
+func (c *GeoCacheContract) GetGeoCachesWithPagination(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PaginatedGeoCaches, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("The page size must be positive!")
+	}
+
+	page := new(PaginatedGeoCaches)
+	page.Caches = []*GeoCache{}
+	page.Bookmark = bookmark
+
+	for {
+		//asking only for the missing count keeps the bookmark aligned with the consumed records
+		remaining := pageSize - int32(len(page.Caches))
+
+		iterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", remaining, page.Bookmark)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read from world state. %s", err)
+		}
+
+		for iterator.HasNext() {
+			queryResponse, err := iterator.Next()
+			if err != nil {
+				iterator.Close()
+				return nil, fmt.Errorf("Could not read from world state. %s", err)
+			}
+
+			//skip the known non-cache namespaces, their values would unmarshal into empty caches
+			if isNonCacheKey(queryResponse.Key) {
+				continue
+			}
+
+			geoCache := new(GeoCache)
+
+			//skip the values that can not be read as a cache
+			if err = json.Unmarshal(queryResponse.Value, geoCache); err != nil {
+				continue
+			}
+
+			//drafts are not part of the public listing
+			if !geoCache.Published {
+				continue
+			}
+
+			//the salt must not leak in a public listing
+			geoCache.Owner.Salt = ""
+
+			page.Caches = append(page.Caches, geoCache)
+		}
+		iterator.Close()
+
+		page.Bookmark = metadata.Bookmark
+
+		if metadata.Bookmark == "" || int32(len(page.Caches)) >= pageSize {
+			break
+		}
+	}
+
+	page.FetchedRecordsCount = int32(len(page.Caches))
+
+	return page, nil
+}
+
+// GetTagCounts returns how many caches carry each tag
+func (c *GeoCacheContract) GetTagCounts(ctx contractapi.TransactionContextInterface) (map[string]int, error) {
+	counts := map[string]int{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//drafts do not show up in the public tag statistics
+		if !geoCache.Published {
+			return nil
+		}
+
+		for _, tag := range geoCache.Tags {
+			counts[tag]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetCachesWithMissionTrackables returns every cache whose trackable's mission references the given region
+func (c *GeoCacheContract) GetCachesWithMissionTrackables(ctx contractapi.TransactionContextInterface, region string) ([]*GeoCache, error) {
+	if region == "" {
+		return nil, fmt.Errorf("The region must not be empty!")
+	}
+
+	matching := []*GeoCache{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//drafts are not part of the public listing
+		if !geoCache.Published {
+			return nil
+		}
+
+		if strings.Contains(geoCache.Trackable.Mission, region) {
+			matching = append(matching, geoCache)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matching, nil
+}
+
+// get all the reports from a cache
+func (c *GeoCacheContract) GetReports(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) ([]Report, error) {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return nil, fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//if the user is not the owner, throw an error
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return nil, fmt.Errorf("Only the owner can get the reports!")
+	}
+	return geoCache.Reports, nil
+}
+
+// GetReportsByNotifier returns the reports the user filed across every cache, so they
+// can follow the status of their own reports without being the owner of any cache
+func (c *GeoCacheContract) GetReportsByNotifier(ctx contractapi.TransactionContextInterface, user User) ([]ReportWithCache, error) {
+	reports := []ReportWithCache{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		for _, report := range geoCache.Reports {
+			if report.Notifier.Id == user.Id {
+				reports = append(reports, ReportWithCache{CacheId: id, Report: report})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// GetVisitors returns the visitor list of a cache, only for its owner
+func (c *GeoCacheContract) GetVisitors(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) ([]User, error) {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return nil, fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	//if the user is not the owner, throw an error
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return nil, fmt.Errorf("Only the owner can view visitors!")
+	}
+	return geoCache.Visitors, nil
+}
+
+//counts the caches that belong to the given user, verified by credential instead of the
+//free-form display name, so a rename can not reset the per-owner cap
+func countCachesOwnedBy(ctx contractapi.TransactionContextInterface, user User) (int, error) {
+	count := 0
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//the owner id is salted per cache, so it has to be rehashed for every candidate
+		if verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+//checks a stored cache against the current schema rules
+func isValidGeoCacheSchema(geoCache *GeoCache) bool {
+	if geoCache.Name == "" {
+		return false
+	}
+
+	if geoCache.XcoordRange[0] > geoCache.XcoordRange[1] || geoCache.YcoordRange[0] > geoCache.YcoordRange[1] {
+		return false
+	}
+
+	return true
+}
+
+// AuditLedgerSchema returns the ids of the caches that violate the current schema, only for the admin organization
+func (c *GeoCacheContract) AuditLedgerSchema(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	iterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, fmt.Errorf("Could not read from world state. %s", err)
+	}
+	defer iterator.Close()
+
+	violations := []string{}
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Could not read from world state. %s", err)
+		}
+
+		//the account, held trackable and config records are not caches; the config values
+		//are not even JSON, so they would always show up as violations
+		if isNonCacheKey(queryResponse.Key) {
+			continue
+		}
+
+		geoCache := new(GeoCache)
+
+		//a value that can not be read as a cache is a violation itself
+		if err = json.Unmarshal(queryResponse.Value, geoCache); err != nil {
+			violations = append(violations, queryResponse.Key)
+			continue
+		}
+
+		if !isValidGeoCacheSchema(geoCache) {
+			violations = append(violations, queryResponse.Key)
+		}
+	}
+
+	return violations, nil
+}
+
+// GetTrackableOwnershipChain returns the users who handled the trackable in order, derived from its move history
+func (c *GeoCacheContract) GetTrackableOwnershipChain(ctx contractapi.TransactionContextInterface, trackableId string) ([]User, error) {
+	var chain []User
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		if chain != nil || geoCache.Trackable.Id != trackableId {
+			return nil
+		}
+
+		//the move history already holds the handlers in order
+		chain = []User{}
+		for _, move := range geoCache.Trackable.History {
+			chain = append(chain, move.MovedBy)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if chain == nil {
+		return nil, fmt.Errorf("The trackable %s does not exist", trackableId)
+	}
+
+	return chain, nil
+}
+
+// TransferOwnership hands a single cache over to a new owner, only for the current owner
+func (c *GeoCacheContract) TransferOwnership(ctx contractapi.TransactionContextInterface, currentOwner User, geoCacheId string, newOwner User) error {
+	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+	if err != nil {
+		return fmt.Errorf("Could not read from world state. %s", err)
+	} else if !exists {
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+	}
+
+	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+
+	geoCache := new(GeoCache)
+
+	err = json.Unmarshal(bytes, geoCache)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+	}
+
+	if !verifyCredential(geoCache.Owner.Id, currentOwner.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can transfer a cache!")
+	}
+
+	//the successor gets a fresh salt and hash instead of the raw id
+	geoCache.Owner = newOwner
+	geoCache.Owner.Salt = generateRandomString()
+	geoCache.Owner.Id = hashCredential(newOwner.Id, geoCache.Owner.Salt)
+
+	if err = recordAccess(ctx, geoCache, "TransferOwnership"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
+}
+
+// TransferAllCaches reassigns every cache of the departing owner to the successor with fresh salts, returns the count transferred
+func (c *GeoCacheContract) TransferAllCaches(ctx contractapi.TransactionContextInterface, currentOwner User, newOwner User) (int, error) {
+	transferred := 0
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//only the departing owner's caches are touched
+		if !verifyCredential(geoCache.Owner.Id, currentOwner.Id, geoCache.Owner.Salt) {
+			return nil
+		}
+
+		//the successor gets a fresh salt and hash on every cache
+		geoCache.Owner = newOwner
+		geoCache.Owner.Salt = generateRandomString()
+		geoCache.Owner.Id = hashCredential(newOwner.Id, geoCache.Owner.Salt)
+
+		if err := recordAccess(ctx, geoCache, "TransferAllCaches"); err != nil {
+			return err
+		}
+
+		newBytes, _ := json.Marshal(geoCache)
+
+		if err := ctx.GetStub().PutState(id, newBytes); err != nil {
+			return err
+		}
+
+		transferred++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return transferred, nil
+}
+
+// MigrateVisitors converts the legacy visitor entries into timestamped visits, only for the admin organization
+func (c *GeoCacheContract) MigrateVisitors(ctx contractapi.TransactionContextInterface) (int, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+
+	iterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, fmt.Errorf("Could not read from world state. %s", err)
+	}
+	defer iterator.Close()
+
+	migrated := 0
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("Could not read from world state. %s", err)
+		}
+
+		geoCache := new(GeoCache)
+
+		//skip the values that can not be read as a cache
+		if err = json.Unmarshal(queryResponse.Value, geoCache); err != nil {
+			continue
+		}
+
+		//only legacy caches are touched, the new ones already keep a visit log
+		if len(geoCache.VisitLog) > 0 || len(geoCache.Visitors) == 0 {
+			continue
+		}
+
+		//the original visit time is unknown, so the timestamp stays empty
+		for _, visitor := range geoCache.Visitors {
+			geoCache.VisitLog = append(geoCache.VisitLog, Visit{User: visitor})
+			migrated++
+		}
+
+		if err = recordAccess(ctx, geoCache, "MigrateVisitors"); err != nil {
+			return 0, err
+		}
+
+		newBytes, _ := json.Marshal(geoCache)
+
+		if err = ctx.GetStub().PutState(queryResponse.Key, newBytes); err != nil {
+			return 0, err
+		}
+	}
+
+	return migrated, nil
+}
+
+// GetAverageTimeToFirstVisit returns the average seconds between creation and the first visit across the owner's caches
+func (c *GeoCacheContract) GetAverageTimeToFirstVisit(ctx contractapi.TransactionContextInterface, ownerName string) (float64, error) {
+	var total float64
+	count := 0
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//skip the caches of other owners and the ones nobody visited yet
+		if geoCache.Owner.Name != ownerName || len(geoCache.VisitLog) == 0 {
+			return nil
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, geoCache.CreatedAt)
+		if err != nil {
+			return nil
+		}
+
+		firstVisit, err := time.Parse(time.RFC3339, geoCache.VisitLog[0].Timestamp)
+		if err != nil {
+			return nil
+		}
+
+		total += firstVisit.Sub(createdAt).Seconds()
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if count == 0 {
+		return 0, fmt.Errorf("The owner %s has no visited caches!", ownerName)
+	}
+
+	return total / float64(count), nil
+}
+
+// GetCacheSpread returns the maximum distance between the midpoints of any two caches
+func (c *GeoCacheContract) GetCacheSpread(ctx contractapi.TransactionContextInterface) (float64, error) {
+	type midpoint struct {
+		x float64
+		y float64
+	}
+
+	midpoints := []midpoint{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		midpoints = append(midpoints, midpoint{
+			x: float64(geoCache.XcoordRange[0]+geoCache.XcoordRange[1]) / 2,
+			y: float64(geoCache.YcoordRange[0]+geoCache.YcoordRange[1]) / 2,
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(midpoints) < 2 {
+		return 0, fmt.Errorf("There are not enough caches to compute the spread!")
+	}
+
+	//the iterator returns the caches in key order, so the pairwise pass is deterministic
+	var spread float64
+	for i := 0; i < len(midpoints); i++ {
+		for j := i + 1; j < len(midpoints); j++ {
+			distance := math.Hypot(midpoints[i].x-midpoints[j].x, midpoints[i].y-midpoints[j].y)
+			if distance > spread {
+				spread = distance
+			}
+		}
+	}
+
+	return spread, nil
+}
+
+// QueryGeoCachesByOwner returns every cache of the caller with the owner salt stripped.
+// The salt differs per cache, so no single selector can match every stored digest;
+// instead the caches are iterated and the caller's id is verified against each stored owner credential.
+func (c *GeoCacheContract) QueryGeoCachesByOwner(ctx contractapi.TransactionContextInterface, user User) ([]*GeoCache, error) {
+	owned := []*GeoCache{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+			return nil
+		}
+
+		//the per-cache salt never leaves the world state
+		geoCache.Owner.Salt = ""
+		owned = append(owned, geoCache)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return owned, nil
+}
+
+// QueryGeoCachesInBox returns the caches whose coordinate ranges lie inside the bounding box using a CouchDB rich query.
+// Rich queries are only supported by a CouchDB state database; on LevelDB the query fails and the error is surfaced.
+func (c *GeoCacheContract) QueryGeoCachesInBox(ctx contractapi.TransactionContextInterface, minX int, maxX int, minY int, maxY int) ([]*GeoCache, error) {
+	if minX > maxX || minY > maxY {
+		return nil, fmt.Errorf("The bounding box is invalid!")
+	}
+
+	//the selector matches on the scalar bound fields, array elements can not be addressed in
+	//it; drafts are filtered in the database, so they never reach the result set
+	queryString := fmt.Sprintf(`{"selector":{"MinX":{"$gte":%d},"MaxX":{"$lte":%d},"MinY":{"$gte":%d},"MaxY":{"$lte":%d},"Published":true}}`, minX, maxX, minY, maxY)
+
+	iterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, fmt.Errorf("Could not run the rich query, the state database has to be CouchDB. %s", err)
+	}
+	defer iterator.Close()
+
+	matches := []*GeoCache{}
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Could not read from world state. %s", err)
+		}
+
+		geoCache := new(GeoCache)
+
+		//skip the values that can not be read as a cache
+		if err = json.Unmarshal(queryResponse.Value, geoCache); err != nil {
+			continue
+		}
+
+		//the salt must not leak in a public listing
+		geoCache.Owner.Salt = ""
+
+		matches = append(matches, geoCache)
+	}
+
+	return matches, nil
+}
+
+// GetGeoCachesInBoxCreatedBetween returns the caches whose coordinate ranges lie inside the bounding box and that were created inside the time window
+func (c *GeoCacheContract) GetGeoCachesInBoxCreatedBetween(ctx contractapi.TransactionContextInterface, minX int, minY int, maxX int, maxY int, fromUnix int64, toUnix int64) ([]*GeoCache, error) {
+	if minX > maxX || minY > maxY {
+		return nil, fmt.Errorf("The bounding box is invalid!")
+	}
+
+	if fromUnix > toUnix {
+		return nil, fmt.Errorf("The time window is invalid!")
+	}
+
+	matches := []*GeoCache{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//drafts are not part of the public listing
+		if !geoCache.Published {
+			return nil
+		}
+
+		//both coordinate ranges have to lie fully inside the box
+		if geoCache.XcoordRange[0] < minX || geoCache.XcoordRange[1] > maxX ||
+			geoCache.YcoordRange[0] < minY || geoCache.YcoordRange[1] > maxY {
+			return nil
+		}
+
+		//skip the caches without a parseable creation time
+		createdAt, err := time.Parse(time.RFC3339, geoCache.CreatedAt)
+		if err != nil {
+			return nil
+		}
+
+		if createdAt.Unix() < fromUnix || createdAt.Unix() > toUnix {
+			return nil
+		}
+
+		//the salt must not leak in a public listing
+		geoCache.Owner.Salt = ""
+
+		matches = append(matches, geoCache)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
 }
 
-//returns a stretched hash from a given password
-func myHash(s string) string {
-	n := 1
-	for n < 100 {
-		h := sha1.New()
-		h.Write([]byte(s))
-		bs := h.Sum(nil)
-		s = string(bs)
-		n++
-	}
-
-	return hex.EncodeToString([]byte(s))
+//reports whether the two closed intervals share at least one point, touching edges count
+func intervalsOverlap(a [2]int, b [2]int) bool {
+	return a[0] <= b[1] && b[0] <= a[1]
 }
 
-//returns a random string (usually for creating a salt)
-func generateRandomString() string {
-	rand.Seed(time.Now().UnixNano())
-	var letterRunes = []rune("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
-	salt := make([]rune, 8)
-	for i := range salt {
-		salt[i] = letterRunes[rand.Intn(len(letterRunes))]
+// GetGeoCachesIntersecting returns the caches whose coordinate ranges overlap the given
+// viewport, so a map client only has to render what is actually on screen
+func (c *GeoCacheContract) GetGeoCachesIntersecting(ctx contractapi.TransactionContextInterface, viewX [2]int, viewY [2]int) ([]*GeoCache, error) {
+	if viewX[0] > viewX[1] || viewY[0] > viewY[1] {
+		return nil, fmt.Errorf("The viewport is invalid!")
 	}
 
-	return string(salt)
-}
+	matches := []*GeoCache{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//drafts are not part of the public map
+		if !geoCache.Published {
+			return nil
+		}
+
+		//both axes have to overlap for the rectangles to intersect
+		if !intervalsOverlap(geoCache.XcoordRange, viewX) || !intervalsOverlap(geoCache.YcoordRange, viewY) {
+			return nil
+		}
 
-// CreateGeoCache creates a new instance of GeoCache
-func (c *GeoCacheContract) CreateGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheID string, name string, description string, newXcoordRange [2]int, newYcoordRange [2]int, trackableValue string) error {
-	exists, err := c.GeoCacheExists(ctx, geoCacheID)
+		//the salt must not leak in a public listing
+		geoCache.Owner.Salt = ""
+
+		matches = append(matches, geoCache)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Could not read from world state. %s", err)
-	} else if exists {
-		return fmt.Errorf("The asset %s already exists", geoCacheID)
+		return nil, err
 	}
 
-	//create object
-	geoCache := new(GeoCache)
-	geoCache.Name = name
-	geoCache.Description = description
-	geoCache.XcoordRange = newXcoordRange
-	geoCache.YcoordRange = newYcoordRange
-	geoCache.Owner = user
-	geoCache.Owner.Salt = generateRandomString()
-	geoCache.Owner.Id = myHash(user.Id + geoCache.Owner.Salt)
-	geoCache.Reports = []Report{}
-	geoCache.Visitors = []User{}
+	return matches, nil
+}
 
-	//create a trackable
-	trackable := new(Trackable)
-	//with a new random id, and the give value
-	trackable.Id = generateRandomString()
-	trackable.Value = trackableValue
+// SearchReports returns the cache's reports whose message contains the keyword, only for the owner
+func (c *GeoCacheContract) SearchReports(ctx contractapi.TransactionContextInterface, owner User, geoCacheId string, keyword string) ([]Report, error) {
+	if keyword == "" {
+		return nil, fmt.Errorf("The search keyword must not be empty!")
+	}
 
-	geoCache.Trackable = *trackable
+	reports, err := c.GetReports(ctx, owner, geoCacheId)
+	if err != nil {
+		return nil, err
+	}
 
-	bytes, _ := json.Marshal(geoCache)
+	matches := []Report{}
+	for _, report := range reports {
+		//the keyword is matched case insensitively
+		if strings.Contains(strings.ToLower(report.Message), strings.ToLower(keyword)) {
+			matches = append(matches, report)
+		}
+	}
 
-	return ctx.GetStub().PutState(geoCacheID, bytes)
+	return matches, nil
 }
 
-// ReadGeoCache retrieves an instance of GeoCache from the world state
-func (c *GeoCacheContract) ReadGeoCache(ctx contractapi.TransactionContextInterface, geoCacheId string) (*GeoCache, error) {
+// AssignReport hands a report over to a co-maintainer by name, only for the owner
+func (c *GeoCacheContract) AssignReport(ctx contractapi.TransactionContextInterface, owner User, geoCacheId string, reportId string, assigneeName string) error {
 	exists, err := c.GeoCacheExists(ctx, geoCacheId)
 	if err != nil {
-		return nil, fmt.Errorf("Could not read from world state. %s", err)
+		return fmt.Errorf("Could not read from world state. %s", err)
 	} else if !exists {
-		return nil, fmt.Errorf("The asset %s does not exist", geoCacheId)
+		return fmt.Errorf("The asset %s does not exist", geoCacheId)
 	}
 
 	bytes, _ := ctx.GetStub().GetState(geoCacheId)
@@ -105,16 +3145,38 @@ func (c *GeoCacheContract) ReadGeoCache(ctx contractapi.TransactionContextInterf
 	geoCache := new(GeoCache)
 
 	err = json.Unmarshal(bytes, geoCache)
-
 	if err != nil {
-		return nil, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
 	}
 
-	return geoCache, nil
+	if !verifyCredential(geoCache.Owner.Id, owner.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can assign a report!")
+	}
+
+	assigned := false
+	for i := range geoCache.Reports {
+		if geoCache.Reports[i].Id == reportId {
+			geoCache.Reports[i].Assignee = assigneeName
+			assigned = true
+			break
+		}
+	}
+
+	if !assigned {
+		return fmt.Errorf("The report %s does not exist!", reportId)
+	}
+
+	if err = recordAccess(ctx, geoCache, "AssignReport"); err != nil {
+		return err
+	}
+
+	newBytes, _ := json.Marshal(geoCache)
+
+	return ctx.GetStub().PutState(geoCacheId, newBytes)
 }
 
-// UpdateGeoCache retrieves an instance of GeoCache from the world state and updates its value
-func (c *GeoCacheContract) UpdateGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, newName string, newDescription string) error {
+// ResolveReport closes a report with a resolution note, only for the owner
+func (c *GeoCacheContract) ResolveReport(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, reportId string, resolution string) error {
 	exists, err := c.GeoCacheExists(ctx, geoCacheId)
 	if err != nil {
 		return fmt.Errorf("Could not read from world state. %s", err)
@@ -131,21 +3193,41 @@ func (c *GeoCacheContract) UpdateGeoCache(ctx contractapi.TransactionContextInte
 		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
 	}
 
-	//if the user is not the owner, throw an error
-	if geoCache.Owner.Id != myHash(user.Id+geoCache.Owner.Salt) {
-		return fmt.Errorf("Only the owner can update a cache!")
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can resolve a report!")
 	}
 
-	geoCache.Name = newName
-	geoCache.Description = newDescription
+	seconds, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	resolved := false
+	for i := range geoCache.Reports {
+		if geoCache.Reports[i].Id == reportId {
+			geoCache.Reports[i].Resolved = true
+			geoCache.Reports[i].ResolvedAt = seconds
+			geoCache.Reports[i].Resolution = resolution
+			resolved = true
+			break
+		}
+	}
+
+	if !resolved {
+		return fmt.Errorf("The report %s does not exist!", reportId)
+	}
+
+	if err = recordAccess(ctx, geoCache, "ResolveReport"); err != nil {
+		return err
+	}
 
 	newBytes, _ := json.Marshal(geoCache)
 
 	return ctx.GetStub().PutState(geoCacheId, newBytes)
 }
 
-// UpdateGeoCache retrieves an instance of GeoCache from the world state and updates its value
-func (c *GeoCacheContract) AddVisitorToGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, Xcoord int, Ycoord int) error {
+// DeleteReport removes a report from the cache entirely, only for the owner
+func (c *GeoCacheContract) DeleteReport(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, reportId string) error {
 	exists, err := c.GeoCacheExists(ctx, geoCacheId)
 	if err != nil {
 		return fmt.Errorf("Could not read from world state. %s", err)
@@ -158,34 +3240,234 @@ func (c *GeoCacheContract) AddVisitorToGeoCache(ctx contractapi.TransactionConte
 	geoCache := new(GeoCache)
 
 	err = json.Unmarshal(bytes, geoCache)
-
 	if err != nil {
 		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
 	}
 
-	Xin := Xcoord > geoCache.XcoordRange[0] && Xcoord < geoCache.XcoordRange[1]
-	Yin := Ycoord > geoCache.YcoordRange[0] && Ycoord < geoCache.YcoordRange[1]
+	if !verifyCredential(geoCache.Owner.Id, user.Id, geoCache.Owner.Salt) {
+		return fmt.Errorf("Only the owner can delete a report!")
+	}
 
-	//if the user's coordinates not in the cache's range, throw an error
-	if !Xin || !Yin {
-		return fmt.Errorf("You are not in the cache's location range!")
+	deleted := false
+	for i := range geoCache.Reports {
+		if geoCache.Reports[i].Id == reportId {
+			geoCache.Reports = append(geoCache.Reports[:i], geoCache.Reports[i+1:]...)
+			deleted = true
+			break
+		}
+	}
+
+	if !deleted {
+		return fmt.Errorf("The report %s does not exist!", reportId)
 	}
 
-	//add the user to the visitors log
-	geoCache.Visitors = append(geoCache.Visitors, user)
+	if err = recordAccess(ctx, geoCache, "DeleteReport"); err != nil {
+		return err
+	}
 
 	newBytes, _ := json.Marshal(geoCache)
 
 	return ctx.GetStub().PutState(geoCacheId, newBytes)
 }
 
-//switches the given cache's and user's trackables
-func (c *GeoCacheContract) SwitchTrackable(ctx contractapi.TransactionContextInterface, trackable Trackable, geoCacheId string) (*Trackable, error) {
+// GetSeverityDistribution returns how many of the cache's reports carry each severity level, only for the owner
+func (c *GeoCacheContract) GetSeverityDistribution(ctx contractapi.TransactionContextInterface, owner User, geoCacheId string) (map[string]int, error) {
+	reports, err := c.GetReports(ctx, owner, geoCacheId)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := map[string]int{}
+	for _, report := range reports {
+		//the legacy reports without a severity are counted as unspecified
+		severity := report.Severity
+		if severity == "" {
+			severity = "UNSPECIFIED"
+		}
+		distribution[severity]++
+	}
+
+	return distribution, nil
+}
+
+// ArchiveStaleReports marks every open report older than the given cutoff as archived, only for the admin organization
+func (c *GeoCacheContract) ArchiveStaleReports(ctx contractapi.TransactionContextInterface, olderThanUnix int64) (int, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+
+	archived := 0
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		changed := false
+		for i := range geoCache.Reports {
+			if !geoCache.Reports[i].Archived && geoCache.Reports[i].CreatedAt < olderThanUnix {
+				geoCache.Reports[i].Archived = true
+				changed = true
+				archived++
+			}
+		}
+
+		if !changed {
+			return nil
+		}
+
+		if err := recordAccess(ctx, geoCache, "ArchiveStaleReports"); err != nil {
+			return err
+		}
+
+		newBytes, _ := json.Marshal(geoCache)
+
+		return ctx.GetStub().PutState(id, newBytes)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return archived, nil
+}
+
+// GetGeoCacheGeoJSON returns the cache's coordinate range as a GeoJSON bounding box feature
+func (c *GeoCacheContract) GetGeoCacheGeoJSON(ctx contractapi.TransactionContextInterface, geoCacheId string) (string, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return "", err
+	}
+
+	minX, maxX := geoCache.XcoordRange[0], geoCache.XcoordRange[1]
+	minY, maxY := geoCache.YcoordRange[0], geoCache.YcoordRange[1]
+
+	//the four corners of the bounding box, closed back to the first point
+	ring := [][2]int{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}, {minX, minY}}
+
+	feature := map[string]interface{}{
+		"type": "Feature",
+		"geometry": map[string]interface{}{
+			"type":        "Polygon",
+			"coordinates": [][][2]int{ring},
+		},
+		"properties": map[string]interface{}{
+			"id":   geoCacheId,
+			"name": geoCache.Name,
+		},
+	}
+
+	bytes, err := json.Marshal(feature)
+	if err != nil {
+		return "", fmt.Errorf("Could not marshal the GeoJSON feature")
+	}
+
+	return string(bytes), nil
+}
+
+//gpxWaypoint is one <wpt> element of a GPX document
+type gpxWaypoint struct {
+	XMLName     xml.Name `xml:"wpt"`
+	Latitude    float64  `xml:"lat,attr"`
+	Longitude   float64  `xml:"lon,attr"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"desc"`
+}
+
+//gpxDocument is the root element of a GPX document
+type gpxDocument struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+}
+
+// ExportGeoCacheGPX returns the cache as a minimal GPX document with a single waypoint
+func (c *GeoCacheContract) ExportGeoCacheGPX(ctx contractapi.TransactionContextInterface, geoCacheId string) (string, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return "", err
+	}
+
+	if geoCache.Latitude == 0 && geoCache.Longitude == 0 {
+		return "", fmt.Errorf("The cache %s has no GPS coordinates!", geoCacheId)
+	}
+
+	document := new(gpxDocument)
+	document.Version = "1.1"
+	document.Creator = "GeoCache"
+	document.Waypoints = []gpxWaypoint{{
+		Latitude:    geoCache.Latitude,
+		Longitude:   geoCache.Longitude,
+		Name:        geoCache.Name,
+		Description: geoCache.Description,
+	}}
+
+	//the xml marshaller also escapes the special characters of the fields
+	bytes, err := xml.Marshal(document)
+	if err != nil {
+		return "", fmt.Errorf("Could not marshal the GPX document")
+	}
+
+	return xml.Header + string(bytes), nil
+}
+
+// ExportAllGeoCachesGPX returns one GPX document with a waypoint for every GPS-enabled cache, ordered by id
+func (c *GeoCacheContract) ExportAllGeoCachesGPX(ctx contractapi.TransactionContextInterface) (string, error) {
+	type keyedWaypoint struct {
+		id       string
+		waypoint gpxWaypoint
+	}
+
+	waypoints := []keyedWaypoint{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//the caches without GPS coordinates can not be exported
+		if geoCache.Latitude == 0 && geoCache.Longitude == 0 {
+			return nil
+		}
+
+		waypoints = append(waypoints, keyedWaypoint{id: id, waypoint: gpxWaypoint{
+			Latitude:    geoCache.Latitude,
+			Longitude:   geoCache.Longitude,
+			Name:        geoCache.Name,
+			Description: geoCache.Description,
+		}})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	//the waypoints are ordered by id so the export stays deterministic
+	sort.Slice(waypoints, func(i, j int) bool {
+		return waypoints[i].id < waypoints[j].id
+	})
+
+	document := new(gpxDocument)
+	document.Version = "1.1"
+	document.Creator = "GeoCache"
+	document.Waypoints = []gpxWaypoint{}
+	for _, keyed := range waypoints {
+		document.Waypoints = append(document.Waypoints, keyed.waypoint)
+	}
+
+	bytes, err := xml.Marshal(document)
+	if err != nil {
+		return "", fmt.Errorf("Could not marshal the GPX document")
+	}
+
+	return xml.Header + string(bytes), nil
+}
+
+//computes the popularity of a cache from its finds, favorites and visitors
+func computePopularityScore(geoCache *GeoCache) int {
+	//a favorite weighs more than a plain find
+	return len(geoCache.VisitLog) + len(geoCache.Visitors) + 2*len(geoCache.FavoritedBy)
+}
+
+// RefreshPopularityScore recomputes the popularity of a cache and stores the snapshot on it, returns the new score
+func (c *GeoCacheContract) RefreshPopularityScore(ctx contractapi.TransactionContextInterface, geoCacheId string) (int, error) {
 	exists, err := c.GeoCacheExists(ctx, geoCacheId)
 	if err != nil {
-		return nil, fmt.Errorf("Could not read from world state. %s", err)
+		return 0, fmt.Errorf("Could not read from world state. %s", err)
 	} else if !exists {
-		return nil, fmt.Errorf("The asset %s does not exist", geoCacheId)
+		return 0, fmt.Errorf("The asset %s does not exist", geoCacheId)
 	}
 
 	bytes, _ := ctx.GetStub().GetState(geoCacheId)
@@ -194,126 +3476,367 @@ func (c *GeoCacheContract) SwitchTrackable(ctx contractapi.TransactionContextInt
 
 	err = json.Unmarshal(bytes, geoCache)
 	if err != nil {
-		return nil, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+		return 0, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
 	}
 
-	cacheTrackable := geoCache.Trackable
-	geoCache.Trackable = trackable
+	geoCache.PopularityScore = computePopularityScore(geoCache)
+
+	if err = recordAccess(ctx, geoCache, "RefreshPopularityScore"); err != nil {
+		return 0, err
+	}
 
 	newBytes, _ := json.Marshal(geoCache)
 
-	return &cacheTrackable, ctx.GetStub().PutState(geoCacheId, newBytes)
+	if err = ctx.GetStub().PutState(geoCacheId, newBytes); err != nil {
+		return 0, err
+	}
+
+	return geoCache.PopularityScore, nil
 }
 
-// UpdateGeoCache retrieves two list of new koordinates of GeoCache from the world state and updates its value
-func (c *GeoCacheContract) UpdateCoordGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string, newXcoordRange [2]int, newYcoordRange [2]int) error {
-	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+// GetMostPopularCaches returns the top caches by their stored popularity snapshots
+func (c *GeoCacheContract) GetMostPopularCaches(ctx contractapi.TransactionContextInterface, limit int) ([]CachePopularity, error) {
+	popularity := []CachePopularity{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		popularity = append(popularity, CachePopularity{Id: id, Score: geoCache.PopularityScore})
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Could not read from world state. %s", err)
-	} else if !exists {
-		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+		return nil, err
 	}
 
-	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+	//the most popular caches come first, ties are broken by id to stay deterministic
+	sort.Slice(popularity, func(i, j int) bool {
+		if popularity[i].Score != popularity[j].Score {
+			return popularity[i].Score > popularity[j].Score
+		}
+		return popularity[i].Id < popularity[j].Id
+	})
 
-	geoCache := new(GeoCache)
+	if limit > 0 && limit < len(popularity) {
+		popularity = popularity[:limit]
+	}
 
-	err = json.Unmarshal(bytes, geoCache)
+	return popularity, nil
+}
+
+// GetOwnerResponsivenessRanking ranks the owners by how fast they resolve reports, only for the admin organization
+func (c *GeoCacheContract) GetOwnerResponsivenessRanking(ctx contractapi.TransactionContextInterface, limit int) ([]OwnerResponsiveness, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	//collect the resolution times of every owner across their caches
+	sums := make(map[string]int64)
+	counts := make(map[string]int)
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		for _, report := range geoCache.Reports {
+			//only a resolved report with both timestamps can be measured
+			if !report.Resolved || report.ResolvedAt == 0 || report.CreatedAt == 0 {
+				continue
+			}
+
+			sums[geoCache.Owner.Name] += report.ResolvedAt - report.CreatedAt
+			counts[geoCache.Owner.Name]++
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+		return nil, err
 	}
 
-	//if the user is not the owner, throw an error
-	if geoCache.Owner.Id != myHash(user.Id+geoCache.Owner.Salt) {
-		return fmt.Errorf("Only the owner can update a cache!")
+	ranking := []OwnerResponsiveness{}
+	for owner, count := range counts {
+		ranking = append(ranking, OwnerResponsiveness{Owner: owner, AverageSeconds: float64(sums[owner]) / float64(count)})
 	}
 
-	geoCache.XcoordRange = newXcoordRange
-	geoCache.YcoordRange = newYcoordRange
+	//the fastest owners come first, ties are broken by name to stay deterministic
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].AverageSeconds != ranking[j].AverageSeconds {
+			return ranking[i].AverageSeconds < ranking[j].AverageSeconds
+		}
+		return ranking[i].Owner < ranking[j].Owner
+	})
 
-	newBytes, _ := json.Marshal(geoCache)
+	if limit > 0 && limit < len(ranking) {
+		ranking = ranking[:limit]
+	}
 
-	return ctx.GetStub().PutState(geoCacheId, newBytes)
+	return ranking, nil
 }
 
-// DeleteGeoCache deletes an instance of GeoCache from the world state
-func (c *GeoCacheContract) DeleteGeoCache(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) error {
-	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+// GetTopRatedCaches returns the best rated caches that have enough ratings to qualify
+func (c *GeoCacheContract) GetTopRatedCaches(ctx contractapi.TransactionContextInterface, limit int) ([]CacheRating, error) {
+	ratings := []CacheRating{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		//a cache needs a minimum number of ratings to qualify
+		if len(geoCache.Reviews) < minRatingsToQualify {
+			return nil
+		}
+
+		total := 0
+		for _, review := range geoCache.Reviews {
+			total += review.Stars
+		}
+
+		ratings = append(ratings, CacheRating{Id: id, AverageRating: float64(total) / float64(len(geoCache.Reviews))})
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Could not read from world state. %s", err)
-	} else if !exists {
-		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+		return nil, err
 	}
 
-	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+	//the best rated caches come first, ties are broken by id to stay deterministic
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].AverageRating != ratings[j].AverageRating {
+			return ratings[i].AverageRating > ratings[j].AverageRating
+		}
+		return ratings[i].Id < ratings[j].Id
+	})
 
-	geoCache := new(GeoCache)
+	if limit > 0 && limit < len(ratings) {
+		ratings = ratings[:limit]
+	}
 
-	err = json.Unmarshal(bytes, geoCache)
+	return ratings, nil
+}
+
+// GetCachesByReportCount returns the top caches by open report count, only for the admin organization
+func (c *GeoCacheContract) GetCachesByReportCount(ctx contractapi.TransactionContextInterface, limit int) ([]CacheReportCount, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	counts := []CacheReportCount{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		open := 0
+		for _, report := range geoCache.Reports {
+			if !report.Archived {
+				open++
+			}
+		}
+
+		counts = append(counts, CacheReportCount{Id: id, Count: open})
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+		return nil, err
 	}
 
-	//if the user is not the owner, throw an error
-	if geoCache.Owner.Id != myHash(user.Id+geoCache.Owner.Salt) {
-		return fmt.Errorf("Only the owner can update a cache!")
+	//the most reported caches come first, ties are broken by id to stay deterministic
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Id < counts[j].Id
+	})
+
+	if limit > 0 && limit < len(counts) {
+		counts = counts[:limit]
 	}
 
-	return ctx.GetStub().DelState(geoCacheId)
+	return counts, nil
 }
 
-//ReportGeoCache make a report for a cache
-func (c *GeoCacheContract) ReportGeoCache(ctx contractapi.TransactionContextInterface, user User, message string, geoCacheId string) error {
-	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+// GetGeoCacheHistory returns every historical version of a cache from the ledger history, oldest first as the peer serves them
+func (c *GeoCacheContract) GetGeoCacheHistory(ctx contractapi.TransactionContextInterface, geoCacheId string) ([]GeoCacheHistoryEntry, error) {
+	iterator, err := ctx.GetStub().GetHistoryForKey(geoCacheId)
 	if err != nil {
-		return fmt.Errorf("Could not read from world state. %s", err)
-	} else if !exists {
-		return fmt.Errorf("The asset %s does not exist", geoCacheId)
+		return nil, fmt.Errorf("Could not read the key history. %s", err)
+	}
+	defer iterator.Close()
+
+	history := []GeoCacheHistoryEntry{}
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Could not read the key history. %s", err)
+		}
+
+		entry := new(GeoCacheHistoryEntry)
+		entry.TxId = modification.TxId
+		entry.IsDelete = modification.IsDelete
+
+		if modification.Timestamp != nil {
+			entry.Timestamp = formatTimestamp(modification.Timestamp.Seconds)
+		}
+
+		//a deletion has no value and a foreign value is left out of the history
+		if !modification.IsDelete {
+			geoCache := new(GeoCache)
+			if err = json.Unmarshal(modification.Value, geoCache); err == nil {
+				//the salt must not leak from a historical version either
+				geoCache.Owner.Salt = ""
+				entry.Value = geoCache
+			}
+		}
+
+		history = append(history, *entry)
 	}
 
-	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+	return history, nil
+}
 
-	geoCache := new(GeoCache)
+// VerifyTrackableUniqueness checks that no trackable id sits in more than one cache and returns the offending ids
+func (c *GeoCacheContract) VerifyTrackableUniqueness(ctx contractapi.TransactionContextInterface) (bool, []string, error) {
+	//count how many caches hold each trackable id
+	counts := make(map[string]int)
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		if geoCache.Trackable.Id != "" {
+			counts[geoCache.Trackable.Id]++
+		}
+		return nil
+	})
+	if err != nil {
+		return false, nil, err
+	}
 
-	err = json.Unmarshal(bytes, geoCache)
+	duplicated := []string{}
+	for trackableId, count := range counts {
+		if count > 1 {
+			duplicated = append(duplicated, trackableId)
+		}
+	}
+	sort.Strings(duplicated)
+
+	return len(duplicated) == 0, duplicated, nil
+}
+
+// GetRecentStatusChanges returns the lifecycle transitions of every cache after the cutoff, only for the admin organization
+func (c *GeoCacheContract) GetRecentStatusChanges(ctx contractapi.TransactionContextInterface, sinceUnix int64) ([]StatusChange, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	changes := []StatusChange{}
+
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		iterator, err := ctx.GetStub().GetHistoryForKey(id)
+		if err != nil {
+			return fmt.Errorf("Could not read the key history. %s", err)
+		}
+		defer iterator.Close()
+
+		//collect the status of every surviving version with its timestamp
+		type version struct {
+			seconds int64
+			status  string
+		}
+		versions := []version{}
+		for iterator.HasNext() {
+			modification, err := iterator.Next()
+			if err != nil {
+				return fmt.Errorf("Could not read the key history. %s", err)
+			}
+
+			if modification.IsDelete || modification.Timestamp == nil {
+				continue
+			}
+
+			historical := new(GeoCache)
+			if err := json.Unmarshal(modification.Value, historical); err != nil {
+				continue
+			}
+
+			versions = append(versions, version{seconds: modification.Timestamp.Seconds, status: historical.Status})
+		}
+
+		//the peer may serve the history in either direction
+		sort.Slice(versions, func(i, j int) bool { return versions[i].seconds < versions[j].seconds })
+
+		for i := 1; i < len(versions); i++ {
+			if versions[i].status == versions[i-1].status || versions[i].seconds < sinceUnix {
+				continue
+			}
+
+			change := new(StatusChange)
+			change.CacheId = id
+			change.OldStatus = versions[i-1].status
+			change.NewStatus = versions[i].status
+			change.Timestamp = formatTimestamp(versions[i].seconds)
+			changes = append(changes, *change)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+		return nil, err
 	}
 
-	//create a report object and save to the cache's reports
-	report := new(Report)
-	report.Id = generateRandomString()
-	report.Message = message
-	report.Notifier = user
+	return changes, nil
+}
 
-	geoCache.Reports = append(geoCache.Reports, *report)
+// GetGeoCacheSummary returns a lightweight view of a cache for the listing screens
+func (c *GeoCacheContract) GetGeoCacheSummary(ctx contractapi.TransactionContextInterface, geoCacheId string) (*GeoCacheSummary, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return nil, err
+	}
 
-	newBytes, _ := json.Marshal(geoCache)
+	summary := new(GeoCacheSummary)
+	summary.Id = geoCache.Id
+	summary.Name = geoCache.Name
+	summary.Description = geoCache.Description
+	summary.XcoordRange = geoCache.XcoordRange
+	summary.YcoordRange = geoCache.YcoordRange
+	summary.VisitorCount = len(geoCache.Visitors)
+
+	//an unreviewed cache keeps the zero average
+	if len(geoCache.Reviews) > 0 {
+		total := 0
+		for _, review := range geoCache.Reviews {
+			total += review.Stars
+		}
+		summary.AverageRating = float64(total) / float64(len(geoCache.Reviews))
+	}
 
-	return ctx.GetStub().PutState(geoCacheId, newBytes)
+	return summary, nil
 }
 
-// get all the reports from a cache
-func (c *GeoCacheContract) GetReports(ctx contractapi.TransactionContextInterface, user User, geoCacheId string) ([]Report, error) {
-	exists, err := c.GeoCacheExists(ctx, geoCacheId)
+// GetHint returns the decoded hint of a cache
+func (c *GeoCacheContract) GetHint(ctx contractapi.TransactionContextInterface, geoCacheId string) (string, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
 	if err != nil {
-		return nil, fmt.Errorf("Could not read from world state. %s", err)
-	} else if !exists {
-		return nil, fmt.Errorf("The asset %s does not exist", geoCacheId)
+		return "", err
 	}
 
-	bytes, _ := ctx.GetStub().GetState(geoCacheId)
+	if geoCache.Hint == "" {
+		return "", fmt.Errorf("The cache %s has no hint!", geoCacheId)
+	}
 
-	geoCache := new(GeoCache)
+	return decodeHint(geoCache.Hint), nil
+}
 
-	err = json.Unmarshal(bytes, geoCache)
+// GetLastModifier returns the client identity that performed the most recent modification of a cache
+func (c *GeoCacheContract) GetLastModifier(ctx contractapi.TransactionContextInterface, geoCacheId string) (string, error) {
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
 	if err != nil {
-		return nil, fmt.Errorf("Could not unmarshal world state data to type GeoCache")
+		return "", err
 	}
 
-	//if the user is not the owner, throw an error
-	if geoCache.Owner.Id != myHash(user.Id+geoCache.Owner.Salt) {
-		return nil, fmt.Errorf("Only the owner can get the reports!")
+	if len(geoCache.AccessLog) == 0 {
+		return "", fmt.Errorf("The cache %s has no access log entries!", geoCacheId)
 	}
-	return geoCache.Reports, nil
+
+	//the access log is append only, so the last entry is the most recent one
+	return geoCache.AccessLog[len(geoCache.AccessLog)-1].ClientId, nil
+}
+
+// GetAuditTrail returns the chronological access log of a cache, only for the admin organization
+func (c *GeoCacheContract) GetAuditTrail(ctx contractapi.TransactionContextInterface, geoCacheId string) ([]AccessEntry, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	geoCache, err := c.ReadGeoCache(ctx, geoCacheId)
+	if err != nil {
+		return nil, err
+	}
+
+	return geoCache.AccessLog, nil
 }
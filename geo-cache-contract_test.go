@@ -6,12 +6,18 @@ package main
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"math"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -35,12 +41,145 @@ func (ms *MockStub) PutState(key string, value []byte) error {
 	return args.Error(0)
 }
 
+//the composite key format is deterministic, so the mock delegates to the real shim implementation
+func (ms *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (ms *MockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	//mirrors the shim implementation, the components are separated by zero bytes
+	componentIndex := 1
+	components := []string{}
+	for i := 1; i < len(compositeKey); i++ {
+		if compositeKey[i] == 0 {
+			components = append(components, compositeKey[componentIndex:i])
+			componentIndex = i + 1
+		}
+	}
+
+	return components[0], components[1:], nil
+}
+
+func (ms *MockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	args := ms.Called(objectType, keys)
+
+	return args.Get(0).(shim.StateQueryIteratorInterface), args.Error(1)
+}
+
 func (ms *MockStub) DelState(key string) error {
 	args := ms.Called(key)
 
 	return args.Error(0)
 }
 
+func (ms *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	args := ms.Called()
+
+	return args.Get(0).(*timestamp.Timestamp), args.Error(1)
+}
+
+func (ms *MockStub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	args := ms.Called(startKey, endKey)
+
+	return args.Get(0).(shim.StateQueryIteratorInterface), args.Error(1)
+}
+
+func (ms *MockStub) SetEvent(name string, payload []byte) error {
+	args := ms.Called(name, payload)
+
+	return args.Error(0)
+}
+
+func (ms *MockStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	args := ms.Called(query)
+
+	return args.Get(0).(shim.StateQueryIteratorInterface), args.Error(1)
+}
+
+func (ms *MockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	args := ms.Called(key)
+
+	return args.Get(0).(shim.HistoryQueryIteratorInterface), args.Error(1)
+}
+
+func (ms *MockStub) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	args := ms.Called(startKey, endKey, pageSize, bookmark)
+
+	return args.Get(0).(shim.StateQueryIteratorInterface), args.Get(1).(*peer.QueryResponseMetadata), args.Error(2)
+}
+
+type MockStateQueryIterator struct {
+	shim.StateQueryIteratorInterface
+	mock.Mock
+}
+
+func (mi *MockStateQueryIterator) HasNext() bool {
+	args := mi.Called()
+
+	return args.Bool(0)
+}
+
+func (mi *MockStateQueryIterator) Next() (*queryresult.KV, error) {
+	args := mi.Called()
+
+	return args.Get(0).(*queryresult.KV), args.Error(1)
+}
+
+func (mi *MockStateQueryIterator) Close() error {
+	args := mi.Called()
+
+	return args.Error(0)
+}
+
+//builds an iterator mock that walks through the given key-value pairs once
+func configureIterator(kvs ...*queryresult.KV) *MockStateQueryIterator {
+	mi := new(MockStateQueryIterator)
+	for _, kv := range kvs {
+		mi.On("HasNext").Return(true).Once()
+		mi.On("Next").Return(kv, nil).Once()
+	}
+	mi.On("HasNext").Return(false)
+	mi.On("Close").Return(nil)
+
+	return mi
+}
+
+type MockHistoryQueryIterator struct {
+	shim.HistoryQueryIteratorInterface
+	mock.Mock
+}
+
+func (mi *MockHistoryQueryIterator) HasNext() bool {
+	args := mi.Called()
+
+	return args.Bool(0)
+}
+
+func (mi *MockHistoryQueryIterator) Next() (*queryresult.KeyModification, error) {
+	args := mi.Called()
+
+	return args.Get(0).(*queryresult.KeyModification), args.Error(1)
+}
+
+func (mi *MockHistoryQueryIterator) Close() error {
+	args := mi.Called()
+
+	return args.Error(0)
+}
+
+//builds a history iterator mock that walks through the given modifications once
+func configureHistoryIterator(modifications ...*queryresult.KeyModification) *MockHistoryQueryIterator {
+	mi := new(MockHistoryQueryIterator)
+	for _, modification := range modifications {
+		mi.On("HasNext").Return(true).Once()
+		mi.On("Next").Return(modification, nil).Once()
+	}
+	mi.On("HasNext").Return(false)
+	mi.On("Close").Return(nil)
+
+	return mi
+}
+
 type MockContext struct {
 	contractapi.TransactionContextInterface
 	mock.Mock
@@ -52,10 +191,55 @@ func (mc *MockContext) GetStub() shim.ChaincodeStubInterface {
 	return args.Get(0).(*MockStub)
 }
 
+func (mc *MockContext) GetClientIdentity() cid.ClientIdentity {
+	args := mc.Called()
+
+	return args.Get(0).(*MockClientIdentity)
+}
+
+type MockClientIdentity struct {
+	cid.ClientIdentity
+	mock.Mock
+}
+
+func (mci *MockClientIdentity) GetID() (string, error) {
+	args := mci.Called()
+
+	return args.String(0), args.Error(1)
+}
+
+func (mci *MockClientIdentity) GetMSPID() (string, error) {
+	args := mci.Called()
+
+	return args.String(0), args.Error(1)
+}
+
+func (mci *MockClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	args := mci.Called(attrName)
+
+	return args.String(0), args.Bool(1), args.Error(2)
+}
+
 func configureStub() (*MockContext, *MockStub) {
+	return configureStubWith("Org1MSP", "", false)
+}
+
+//configures the mocks with the calling client placed in the given MSP
+func configureStubAs(mspId string) (*MockContext, *MockStub) {
+	return configureStubWith(mspId, "", false)
+}
+
+//configures the mocks with a caller whose certificate carries the verified visitor attribute
+func configureVerifiedStub() (*MockContext, *MockStub) {
+	return configureStubWith("Org1MSP", "true", true)
+}
+
+//configures the mocks with the calling client placed in the given MSP and the given verified visitor attribute
+func configureStubWith(mspId string, attributeValue string, hasAttribute bool) (*MockContext, *MockStub) {
 	var nilBytes []byte
 
 	testGeoCache := new(GeoCache)
+	testGeoCache.Id = "geoCachekey"
 	testGeoCache.Name = "set value"
 
 	u := new(User)
@@ -80,20 +264,59 @@ func configureStub() (*MockContext, *MockStub) {
 
 	geoCacheBytes, _ := json.Marshal(testGeoCache)
 
+	//a cache protected by a visit code, stored as a hash
+	codedGeoCache := new(GeoCache)
+	codedGeoCache.Name = "coded cache"
+	codedGeoCache.Owner = *u
+	codedGeoCache.XcoordRange = [2]int{5, 10}
+	codedGeoCache.YcoordRange = [2]int{5, 10}
+	codedGeoCache.VisitCode = myHash("opensesame")
+	codedGeoCacheBytes, _ := json.Marshal(codedGeoCache)
+
 	ms := new(MockStub)
 	ms.On("GetState", "statebad").Return(nilBytes, errors.New(getStateError))
 	ms.On("GetState", "missingkey").Return(nilBytes, nil)
 	ms.On("GetState", "existingkey").Return([]byte("some value"), nil)
 	ms.On("GetState", "geoCachekey").Return(geoCacheBytes, nil)
+	ms.On("GetState", "codedCachekey").Return(codedGeoCacheBytes, nil)
+	//the test users have no registered account record by default
+	ms.On("GetState", "user_123").Return(nilBytes, nil)
 	ms.On("PutState", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).Return(nil)
+	ms.On("SetEvent", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).Return(nil)
 	ms.On("DelState", mock.AnythingOfType("string")).Return(nil)
+	ms.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1000000}, nil)
+
+	mci := new(MockClientIdentity)
+	mci.On("GetID").Return("testClient", nil)
+	mci.On("GetMSPID").Return(mspId, nil)
+	mci.On("GetAttributeValue", verifiedVisitorAttribute).Return(attributeValue, hasAttribute, nil)
 
 	mc := new(MockContext)
 	mc.On("GetStub").Return(ms)
+	mc.On("GetClientIdentity").Return(mci)
 
 	return mc, ms
 }
 
+func TestTxTime(t *testing.T) {
+	ctx, _ := configureStub()
+
+	//the helper converts the mocked protobuf timestamp to unix seconds
+	seconds, err := txTime(ctx)
+	assert.Nil(t, err, "should not return error when the timestamp can be read")
+	assert.Equal(t, int64(1000000), seconds, "should return the mocked transaction time in seconds")
+
+	//a failing timestamp read is returned as an error instead of a panic
+	ms := new(MockStub)
+	ms.On("GetTxTimestamp").Return(&timestamp.Timestamp{}, errors.New("timestamp error"))
+
+	mc := new(MockContext)
+	mc.On("GetStub").Return(ms)
+
+	_, err = txTime(mc)
+	assert.EqualError(t, err, "Could not read the transaction timestamp. timestamp error", "should wrap the timestamp error")
+}
+
 func TestGeoCacheExists(t *testing.T) {
 	var exists bool
 	var err error
@@ -120,23 +343,407 @@ func TestGeoCacheExists(t *testing.T) {
 func TestCreateGeoCache(t *testing.T) {
 	var err error
 
-	ctx, _ := configureStub()
+	ctx, stub := configureStub()
 	c := new(GeoCacheContract)
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
 	u := new(User)
 	u.Id = "123"
 	u.Name = "TestUser"
 
+	//an empty id or name is rejected before the world state is touched
+	_, err = c.CreateGeoCache(ctx, *u, "", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The cache id must not be empty!", "should error for an empty cache id")
+
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The cache name must not be empty!", "should error for an empty cache name")
+
 	// statebad returns nilBytes and an error, so the function should return with an error
-	err = c.CreateGeoCache(ctx, *u, "statebad", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, "asd")
+	_, err = c.CreateGeoCache(ctx, *u, "statebad", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
 	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
 
 	// existingkey returns with same valid value, and no error, so the function should return with error, bc the key already exist
-	err = c.CreateGeoCache(ctx, *u, "existingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, "asd")
+	_, err = c.CreateGeoCache(ctx, *u, "existingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
 	assert.EqualError(t, err, "The asset existingkey already exists", "should error when exists returns true")
 
+	//an invalid container size is rejected
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "gigantic", "asd", "", "", "")
+	assert.EqualError(t, err, "The size gigantic is not valid!", "should error for an invalid size")
+
+	//an out of range rating is rejected
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 6, 3, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The difficulty must be between 1 and 5!", "should error for an out of range difficulty")
+
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 0, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The terrain must be between 1 and 5!", "should error for an out of range terrain")
+
 	//create a cache with Create function, and assert that, it does not return an error
-	err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, "asd")
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
 	assert.Nil(t, err)
+
+	//the world state key is persisted on the object as well, together with the ratings
+	stub.AssertCalled(t, "PutState", "missingkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Id == "missingkey" && storedCache.Difficulty == 2 && storedCache.Terrain == 3 &&
+			storedCache.Size == "regular"
+	}))
+}
+
+func TestCreateGeoCachesBatch(t *testing.T) {
+	var err error
+	var nilBytes []byte
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
+	stub.On("GetState", "batchkey1").Return(nilBytes, nil)
+	stub.On("GetState", "batchkey2").Return(nilBytes, nil)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//an empty batch is rejected before the world state is touched
+	_, err = c.CreateGeoCachesBatch(ctx, *u, []GeoCacheInput{})
+	assert.EqualError(t, err, "The batch must not be empty!", "should error for an empty batch")
+
+	//every input of a valid batch is created and its id returned
+	createdIds, err := c.CreateGeoCachesBatch(ctx, *u, []GeoCacheInput{
+		{Id: "batchkey1", Name: "first", XcoordRange: [2]int{5, 10}, YcoordRange: [2]int{5, 10}, Difficulty: 2, Terrain: 3},
+		{Id: "batchkey2", Name: "second", XcoordRange: [2]int{5, 10}, YcoordRange: [2]int{5, 10}, Difficulty: 2, Terrain: 3},
+	})
+	assert.Nil(t, err, "should not return error for an all valid batch")
+	assert.Equal(t, []string{"batchkey1", "batchkey2"}, createdIds, "should return the created ids in order")
+	stub.AssertCalled(t, "PutState", "batchkey1", mock.AnythingOfType("[]uint8"))
+	stub.AssertCalled(t, "PutState", "batchkey2", mock.AnythingOfType("[]uint8"))
+
+	//a duplicated id aborts the whole batch
+	_, err = c.CreateGeoCachesBatch(ctx, *u, []GeoCacheInput{
+		{Id: "batchkey1", Name: "first", XcoordRange: [2]int{5, 10}, YcoordRange: [2]int{5, 10}, Difficulty: 2, Terrain: 3},
+		{Id: "existingkey", Name: "taken", XcoordRange: [2]int{5, 10}, YcoordRange: [2]int{5, 10}, Difficulty: 2, Terrain: 3},
+	})
+	assert.EqualError(t, err, "The asset existingkey already exists", "should error when an id is already taken")
+
+	//the same batch goes through when the taken id is marked skippable
+	createdIds, err = c.CreateGeoCachesBatch(ctx, *u, []GeoCacheInput{
+		{Id: "batchkey1", Name: "first", XcoordRange: [2]int{5, 10}, YcoordRange: [2]int{5, 10}, Difficulty: 2, Terrain: 3},
+		{Id: "existingkey", Name: "taken", XcoordRange: [2]int{5, 10}, YcoordRange: [2]int{5, 10}, Difficulty: 2, Terrain: 3, SkipIfExists: true},
+	})
+	assert.Nil(t, err, "should not return error when the duplicate is skippable")
+	assert.Equal(t, []string{"batchkey1"}, createdIds, "should only return the freshly created ids")
+
+	//a single batch can not push the owner past the cap either, the committed state
+	//alone would never see the caches created earlier in the same batch
+	oversized := []GeoCacheInput{}
+	for i := 0; i <= maxCachesPerOwner; i++ {
+		id := fmt.Sprintf("capkey%d", i)
+		stub.On("GetState", id).Return(nilBytes, nil)
+		oversized = append(oversized, GeoCacheInput{Id: id, Name: fmt.Sprintf("cap %d", i), XcoordRange: [2]int{5, 10}, YcoordRange: [2]int{5, 10}, Difficulty: 2, Terrain: 3})
+	}
+
+	_, err = c.CreateGeoCachesBatch(ctx, *u, oversized)
+	assert.EqualError(t, err, "The owner TestUser already has the maximum number of caches!", "should error when the batch alone exceeds the cap")
+}
+
+func TestSetGeoCacheSize(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	//an invalid size string is rejected before the world state is touched
+	err = c.SetGeoCacheSize(ctx, *owner, "geoCachekey", "gigantic")
+	assert.EqualError(t, err, "The size gigantic is not valid!", "should error for an invalid size")
+
+	//only the owner can classify the container
+	err = c.SetGeoCacheSize(ctx, *stranger, "geoCachekey", "micro")
+	assert.EqualError(t, err, "Only the owner can set the size of a cache!", "should error for a non owner caller")
+
+	//the owner's classification is stored on the cache
+	err = c.SetGeoCacheSize(ctx, *owner, "geoCachekey", "micro")
+	assert.Nil(t, err, "should not return error for the owner")
+	stub.AssertCalled(t, "PutState", "geoCachekey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Size == "micro"
+	}))
+}
+
+func TestUpdateRatings(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	//an out of range rating is rejected before the world state is touched
+	err = c.UpdateRatings(ctx, *owner, "geoCachekey", 0, 3)
+	assert.EqualError(t, err, "The difficulty must be between 1 and 5!", "should error for an out of range difficulty")
+
+	//only the owner can change the ratings
+	err = c.UpdateRatings(ctx, *stranger, "geoCachekey", 2, 3)
+	assert.EqualError(t, err, "Only the owner can update the ratings!", "should error for a non owner caller")
+
+	//the owner's update is stored on the cache
+	err = c.UpdateRatings(ctx, *owner, "geoCachekey", 4, 5)
+	assert.Nil(t, err, "should not return error for the owner")
+	stub.AssertCalled(t, "PutState", "geoCachekey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Difficulty == 4 && storedCache.Terrain == 5
+	}))
+}
+
+func TestGetGeoCacheSummary(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a cache with visitors, reviews and heavy collections
+	fullCache := new(GeoCache)
+	fullCache.Id = "summarykey"
+	fullCache.Name = "summary cache"
+	fullCache.Description = "a busy cache"
+	fullCache.XcoordRange = [2]int{5, 10}
+	fullCache.YcoordRange = [2]int{5, 10}
+	fullCache.Visitors = []User{*u, {Id: "456", Name: "Other"}}
+	fullCache.Reviews = []Review{
+		{Reviewer: *u, Stars: 4},
+		{Reviewer: User{Id: "456"}, Stars: 5},
+	}
+	fullCache.Reports = []Report{{Id: "r1", Message: "broken"}}
+	fullCacheBytes, _ := json.Marshal(fullCache)
+	stub.On("GetState", "summarykey").Return(fullCacheBytes, nil)
+
+	summary, err := c.GetGeoCacheSummary(ctx, "summarykey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Equal(t, "summarykey", summary.Id)
+	assert.Equal(t, "summary cache", summary.Name)
+	assert.Equal(t, [2]int{5, 10}, summary.XcoordRange)
+	assert.Equal(t, 2, summary.VisitorCount, "should count the visitors")
+	assert.Equal(t, 4.5, summary.AverageRating, "should average the review stars")
+
+	//a missing cache surfaces the usual error
+	_, err = c.GetGeoCacheSummary(ctx, "missingkey")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error for a missing cache")
+}
+
+func TestGetHint(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
+
+	//the hint round-trips through the encode and decode pair
+	assert.Equal(t, "under the old oak", decodeHint(encodeHint("under the old oak")), "should round-trip a hint")
+
+	//creation stores the hint in the obfuscated form, never as plaintext
+	_, err := c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "under the old oak")
+	assert.Nil(t, err, "should not return error for a valid cache with a hint")
+	stub.AssertCalled(t, "PutState", "missingkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Hint == encodeHint("under the old oak") && storedCache.Hint != "under the old oak"
+	}))
+
+	//a cache with a stored hint hands back the decoded form
+	hintedCache := new(GeoCache)
+	hintedCache.Name = "hinted cache"
+	hintedCache.Hint = encodeHint("under the old oak")
+	hintedCacheBytes, _ := json.Marshal(hintedCache)
+	stub.On("GetState", "hintedkey").Return(hintedCacheBytes, nil)
+
+	hint, err := c.GetHint(ctx, "hintedkey")
+	assert.Nil(t, err, "should not return error when the cache has a hint")
+	assert.Equal(t, "under the old oak", hint, "should return the decoded hint")
+
+	//the fixture cache has no hint
+	_, err = c.GetHint(ctx, "geoCachekey")
+	assert.EqualError(t, err, "The cache geoCachekey has no hint!", "should error when the cache has no hint")
+}
+
+func TestCreateGeoCacheHintValidation(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a description that duplicates the hint verbatim should be rejected
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "under the old oak", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "under the old oak")
+	assert.EqualError(t, err, "The description must not duplicate the hint!", "should error when the description equals the hint")
+
+	//distinct description and hint should be accepted
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "a nice forest cache", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "under the old oak")
+	assert.Nil(t, err, "should not return error when the description and the hint differ")
+}
+
+func TestCoordSpanLimit(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+	u.Salt = "123"
+
+	//an over-wide range is rejected on creation, naming the axis
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{0, maxCoordRangeSpan + 1}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The X coordinate range is wider than the allowed maximum!", "should error when the X range is too wide")
+
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{0, maxCoordRangeSpan + 1}, 2, 3, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The Y coordinate range is wider than the allowed maximum!", "should error when the Y range is too wide")
+
+	//a range within the limit is accepted
+	_, err = c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{0, maxCoordRangeSpan}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.Nil(t, err, "should not return error when the ranges are within the limit")
+
+	//the same limit applies to coordinate updates
+	err = c.UpdateCoordGeoCache(ctx, *u, "geoCachekey", [2]int{0, maxCoordRangeSpan + 1}, [2]int{5, 10})
+	assert.EqualError(t, err, "The X coordinate range is wider than the allowed maximum!", "should error when the updated X range is too wide")
+}
+
+func TestCreateGeoCacheOwnerCap(t *testing.T) {
+	var err error
+
+	hoarder := new(User)
+	hoarder.Id = "123"
+	hoarder.Name = "Hoarder"
+
+	//the hoarder already holds the maximum number of caches, stored with salted credentials
+	kvs := []*queryresult.KV{}
+	for i := 0; i < maxCachesPerOwner; i++ {
+		ownedCache := new(GeoCache)
+		ownedCache.Name = fmt.Sprintf("cache %d", i)
+		ownedCache.Owner = User{Id: myHash("123" + "salt"), Name: "Hoarder", Salt: "salt"}
+		ownedCacheBytes, _ := json.Marshal(ownedCache)
+		kvs = append(kvs, &queryresult.KV{Key: fmt.Sprintf("owned%d", i), Value: ownedCacheBytes})
+	}
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetStateByRange", "", "").Return(configureIterator(kvs...), nil)
+
+	//an owner at the cap can not create another cache
+	_, err = c.CreateGeoCache(ctx, *hoarder, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The owner Hoarder already has the maximum number of caches!", "should error when the owner is at the cap")
+
+	//the caches of an unrelated user with the same display name do not count against the cap
+	impostorKvs := []*queryresult.KV{kvs[0]}
+	for i := 0; i < maxCachesPerOwner; i++ {
+		impostorCache := new(GeoCache)
+		impostorCache.Name = fmt.Sprintf("impostor cache %d", i)
+		impostorCache.Owner = User{Id: myHash("789" + "salt"), Name: "Hoarder", Salt: "salt"}
+		impostorCacheBytes, _ := json.Marshal(impostorCache)
+		impostorKvs = append(impostorKvs, &queryresult.KV{Key: fmt.Sprintf("impostor%d", i), Value: impostorCacheBytes})
+	}
+
+	ctx2, stub2 := configureStub()
+	stub2.On("GetStateByRange", "", "").Return(configureIterator(impostorKvs...), nil)
+
+	_, err = c.CreateGeoCache(ctx2, *hoarder, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.Nil(t, err, "should not return error when only a same-named other user is at the cap")
+}
+
+func TestCreateGeoCacheAccountAge(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
+
+	//an account registered right before the transaction time
+	newbieAccount := new(UserAccount)
+	newbieAccount.Name = "Newbie"
+	newbieAccount.RegisteredAt = 999999
+	newbieBytes, _ := json.Marshal(newbieAccount)
+	stub.On("GetState", "user_newbie").Return(newbieBytes, nil)
+
+	//an account registered well before the minimum age
+	veteranAccount := new(UserAccount)
+	veteranAccount.Name = "Veteran"
+	veteranAccount.RegisteredAt = 0
+	veteranBytes, _ := json.Marshal(veteranAccount)
+	stub.On("GetState", "user_veteran").Return(veteranBytes, nil)
+
+	newbie := new(User)
+	newbie.Id = "newbie"
+	newbie.Name = "Newbie"
+
+	veteran := new(User)
+	veteran.Id = "veteran"
+	veteran.Name = "Veteran"
+
+	//the newbie's account is younger than the minimum age, so the creation should be rejected
+	_, err = c.CreateGeoCache(ctx, *newbie, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The account is too new to create a cache!", "should error when the account is too new")
+
+	//the veteran's account is old enough, so the creation should succeed
+	_, err = c.CreateGeoCache(ctx, *veteran, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.Nil(t, err, "should not return error when the account is old enough")
+}
+
+func TestRegisterUser(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//the user has no account record yet, so the registration should succeed
+	err = c.RegisterUser(ctx, *u)
+	assert.Nil(t, err, "should not return error when the user is not registered yet")
+
+	expectedAccount := new(UserAccount)
+	expectedAccount.Name = "TestUser"
+	expectedAccount.RegisteredAt = 1000000
+	expectedAccountBytes, _ := json.Marshal(expectedAccount)
+
+	//the account should be stored with the transaction time
+	stub.AssertCalled(t, "PutState", "user_123", expectedAccountBytes)
+
+	//a user with a stored account record can not register again
+	registered := new(User)
+	registered.Id = "veteran"
+	registered.Name = "Veteran"
+	veteranAccount := new(UserAccount)
+	veteranAccount.Name = "Veteran"
+	veteranBytes, _ := json.Marshal(veteranAccount)
+	stub.On("GetState", "user_veteran").Return(veteranBytes, nil)
+
+	err = c.RegisterUser(ctx, *registered)
+	assert.EqualError(t, err, "The user Veteran is already registered", "should error when the user is already registered")
 }
 
 func TestReadGeoCache(t *testing.T) {
@@ -164,6 +771,7 @@ func TestReadGeoCache(t *testing.T) {
 	//expected values
 	geoCache, err = c.ReadGeoCache(ctx, "geoCachekey")
 	expectedGeoCache := new(GeoCache)
+	expectedGeoCache.Id = "geoCachekey"
 	expectedGeoCache.Name = "set value"
 
 	u := new(User)
@@ -204,16 +812,17 @@ func TestUpdateGeoCache(t *testing.T) {
 	c := new(GeoCacheContract)
 
 	// statebad returns nilBytes and an error, so the function should return with error
-	err = c.UpdateGeoCache(ctx, *u, "statebad", "new value", "newDescription")
+	err = c.UpdateGeoCache(ctx, *u, "statebad", "new value", "newDescription", 0)
 	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors when updating")
 
 	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
-	err = c.UpdateGeoCache(ctx, *u, "missingkey", "new value", "newDescription")
+	err = c.UpdateGeoCache(ctx, *u, "missingkey", "new value", "newDescription", 0)
 	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when updating")
 
 	// existingkey returns with same valid value, and no error, so the function should return with the success, and the object should updated
-	err = c.UpdateGeoCache(ctx, *u, "geoCachekey", "new value", "newDescription")
+	err = c.UpdateGeoCache(ctx, *u, "geoCachekey", "new value", "newDescription", 0)
 	expectedGeoCache := new(GeoCache)
+	expectedGeoCache.Id = "geoCachekey"
 	expectedGeoCache.Name = "new value"
 	expectedGeoCache.Description = "newDescription"
 
@@ -237,6 +846,10 @@ func TestUpdateGeoCache(t *testing.T) {
 	expectedGeoCache.Owner = *u2
 	expectedGeoCache.XcoordRange = [2]int{5, 10}
 	expectedGeoCache.YcoordRange = [2]int{5, 10}
+	//the modification is recorded in the access log and bumps the version
+	expectedGeoCache.AccessLog = append(expectedGeoCache.AccessLog, AccessEntry{ClientId: "testClient", Operation: "UpdateGeoCache", Timestamp: "1970-01-12T13:46:40Z"})
+	expectedGeoCache.UpdatedAt = "1970-01-12T13:46:40Z"
+	expectedGeoCache.Version = 1
 	expectedGeoCacheBytes, _ := json.Marshal(expectedGeoCache)
 
 	//does not return an error, because the new user's id and salt combination is equal the stored id hash
@@ -245,7 +858,7 @@ func TestUpdateGeoCache(t *testing.T) {
 	stub.AssertCalled(t, "PutState", "geoCachekey", expectedGeoCacheBytes)
 }
 
-func TestDeleteGeoCache(t *testing.T) {
+func TestUpdateGeoCacheVersioned(t *testing.T) {
 	var err error
 
 	ctx, stub := configureStub()
@@ -256,22 +869,31 @@ func TestDeleteGeoCache(t *testing.T) {
 	u.Name = "TestUser"
 	u.Salt = "123"
 
-	// statebad returns nilBytes and an error, so the function should return with error
-	err = c.DeleteGeoCache(ctx, *u, "statebad")
-	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+	//a cache that has already been modified a few times
+	versionedCache := new(GeoCache)
+	versionedCache.Id = "versionedkey"
+	versionedCache.Name = "versioned cache"
+	versionedCache.Owner = User{Id: myHash("123123"), Name: "TestUser", Salt: "123"}
+	versionedCache.Version = 3
+	versionedCacheBytes, _ := json.Marshal(versionedCache)
+	stub.On("GetState", "versionedkey").Return(versionedCacheBytes, nil)
 
-	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
-	err = c.DeleteGeoCache(ctx, *u, "missingkey")
-	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
+	//a stale expected version is rejected without touching the world state
+	err = c.UpdateGeoCache(ctx, *u, "versionedkey", "new value", "newDescription", 2)
+	assert.EqualError(t, err, "The cache versionedkey was modified concurrently, expected version 2 but found 3!", "should error for a stale expected version")
+	stub.AssertNotCalled(t, "PutState", "versionedkey", mock.AnythingOfType("[]uint8"))
 
-	// geoCachekey returns with a valid value and no error, so the function shouldnt return woth an error and delState should called with the "geoCachekey" value
-	err = c.DeleteGeoCache(ctx, *u, "geoCachekey")
-	assert.Nil(t, err, "should not return error when GeoCache exists in world state when deleting")
-	//del state should called
-	stub.AssertCalled(t, "DelState", "geoCachekey")
+	//the matching expected version goes through and the stored version grows by one
+	err = c.UpdateGeoCache(ctx, *u, "versionedkey", "new value", "newDescription", 3)
+	assert.Nil(t, err, "should not return error for the matching expected version")
+	stub.AssertCalled(t, "PutState", "versionedkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Version == 4 && storedCache.Name == "new value"
+	}))
 }
 
-func TestAddVisitorToGeoCache(t *testing.T) {
+func TestDeleteGeoCache(t *testing.T) {
 	var err error
 
 	ctx, stub := configureStub()
@@ -283,78 +905,3291 @@ func TestAddVisitorToGeoCache(t *testing.T) {
 	u.Salt = "123"
 
 	// statebad returns nilBytes and an error, so the function should return with error
-	err = c.AddVisitorToGeoCache(ctx, *u, "statebad", 6, 6)
+	err = c.DeleteGeoCache(ctx, *u, "statebad", false)
 	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
 
 	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
-	err = c.AddVisitorToGeoCache(ctx, *u, "missingkey", 6, 6)
+	err = c.DeleteGeoCache(ctx, *u, "missingkey", false)
 	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
 
-	// geoCachekey returns with a valid value and no error, so the function shouldnt return with no error, and the given coordinates is in the cache's range
-	err = c.AddVisitorToGeoCache(ctx, *u, "geoCachekey", 6, 6)
+	//the fixture cache still holds its trackable, so a plain delete is blocked
+	err = c.DeleteGeoCache(ctx, *u, "geoCachekey", false)
+	assert.EqualError(t, err, "The cache geoCachekey still holds a trackable!", "should error when the cache holds a trackable")
+	stub.AssertNotCalled(t, "DelState", "geoCachekey")
+
+	//a forced delete goes through and delState is called with the "geoCachekey" value
+	err = c.DeleteGeoCache(ctx, *u, "geoCachekey", true)
 	assert.Nil(t, err, "should not return error when GeoCache exists in world state when deleting")
+	//del state should called
+	stub.AssertCalled(t, "DelState", "geoCachekey")
+}
 
-	expectedGeoCache := new(GeoCache)
-	expectedGeoCache.Name = "set value"
+func TestArchiveGeoCache(t *testing.T) {
+	var err error
 
-	// expected user in the expected cache
-	u2 := new(User)
-	u2.Id = "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba"
-	u2.Name = "TestUser"
-	u2.Salt = "123"
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	err = c.ArchiveGeoCache(ctx, *owner, "missingkey")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error for a missing cache")
+
+	//only the owner can take a cache out of play
+	err = c.ArchiveGeoCache(ctx, *stranger, "geoCachekey")
+	assert.EqualError(t, err, "Only the owner can archive a cache!", "should error for a non owner caller")
+
+	err = c.ArchiveGeoCache(ctx, *owner, "geoCachekey")
+	assert.Nil(t, err, "should not return error when the owner archives")
+
+	//the record is kept in the world state with the archived status instead of being erased
+	stub.AssertNotCalled(t, "DelState", "geoCachekey")
+	stub.AssertCalled(t, "PutState", "geoCachekey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Status == statusArchived
+	}))
+}
+
+func TestAddVisitorToGeoCache(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+	u.Salt = "123"
+
+	// statebad returns nilBytes and an error, so the function should return with error
+	err = c.AddVisitorToGeoCache(ctx, *u, "statebad", 6, 6, "")
+	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+
+	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
+	err = c.AddVisitorToGeoCache(ctx, *u, "missingkey", 6, 6, "")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
+
+	// geoCachekey returns with a valid value and no error, so the function shouldnt return with no error, and the given coordinates is in the cache's range
+	err = c.AddVisitorToGeoCache(ctx, *u, "geoCachekey", 6, 6, "")
+	assert.Nil(t, err, "should not return error when GeoCache exists in world state when deleting")
+
+	expectedGeoCache := new(GeoCache)
+	expectedGeoCache.Id = "geoCachekey"
+	expectedGeoCache.Name = "set value"
+
+	// expected user in the expected cache
+	u2 := new(User)
+	u2.Id = "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba"
+	u2.Name = "TestUser"
+	u2.Salt = "123"
+
+	trackable := new(Trackable)
+	expectedGeoCache.Trackable = *trackable
+	expectedGeoCache.Trackable.Id = "testId"
+	expectedGeoCache.Trackable.Value = "testValue"
+
+	report := new(Report)
+	report.Id = "testId"
+	report.Message = "TestMessage"
+	report.Notifier = *u2
+	expectedGeoCache.Reports = append(expectedGeoCache.Reports, *report)
+
+	expectedGeoCache.Owner = *u2
+	//adding the new visitor
+	expectedGeoCache.Visitors = append(expectedGeoCache.Visitors, *u)
+	//the visit is recorded with the transaction time too
+	expectedGeoCache.VisitLog = append(expectedGeoCache.VisitLog, Visit{User: *u, Xcoord: 6, Ycoord: 6, Timestamp: "1970-01-12T13:46:40Z"})
+	expectedGeoCache.XcoordRange = [2]int{5, 10}
+	expectedGeoCache.YcoordRange = [2]int{5, 10}
+	//the modification is recorded in the access log
+	expectedGeoCache.AccessLog = append(expectedGeoCache.AccessLog, AccessEntry{ClientId: "testClient", Operation: "AddVisitorToGeoCache", Timestamp: "1970-01-12T13:46:40Z"})
+	expectedGeoCache.UpdatedAt = "1970-01-12T13:46:40Z"
+	expectedGeoCache.Version = 1
+	expectedGeoCacheBytes, _ := json.Marshal(expectedGeoCache)
+
+	//put state should called with the expected cache value
+	stub.AssertCalled(t, "PutState", "geoCachekey", expectedGeoCacheBytes)
+}
+
+func TestAddVisitorToGeoCacheVisitCode(t *testing.T) {
+	var err error
+
+	ctx, _ := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//codedCachekey holds a cache with a stored visit code hash, so a wrong code should be rejected
+	err = c.AddVisitorToGeoCache(ctx, *u, "codedCachekey", 6, 6, "wrongcode")
+	assert.EqualError(t, err, "The given visit code is not valid!", "should error when the given code does not match")
+
+	//the correct code's hash matches the stored one, so the visit should succeed
+	err = c.AddVisitorToGeoCache(ctx, *u, "codedCachekey", 6, 6, "opensesame")
+	assert.Nil(t, err, "should not return error when the given code matches")
+
+	//geoCachekey holds a cache without a visit code, so it is open and any code is accepted
+	err = c.AddVisitorToGeoCache(ctx, *u, "geoCachekey", 6, 6, "")
+	assert.Nil(t, err, "should not return error when the cache has no visit code")
+}
+
+func TestGetLastModifier(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//a cache with two recorded modifications in its access log
+	modifiedCache := new(GeoCache)
+	modifiedCache.Name = "modified cache"
+	modifiedCache.AccessLog = append(modifiedCache.AccessLog, AccessEntry{ClientId: "clientA", Operation: "CreateGeoCache", Timestamp: "1970-01-12T13:46:40Z"})
+	modifiedCache.AccessLog = append(modifiedCache.AccessLog, AccessEntry{ClientId: "clientB", Operation: "UpdateGeoCache", Timestamp: "1970-01-12T14:46:40Z"})
+	modifiedCacheBytes, _ := json.Marshal(modifiedCache)
+	stub.On("GetState", "modifiedkey").Return(modifiedCacheBytes, nil)
+
+	//the latest entry of the access log wins
+	modifier, err := c.GetLastModifier(ctx, "modifiedkey")
+	assert.Nil(t, err, "should not return error when the cache has an access log")
+	assert.Equal(t, "clientB", modifier, "should return the most recent modifier")
+
+	//a cache without access log entries can not answer the question
+	emptyCache := new(GeoCache)
+	emptyCache.Name = "empty cache"
+	emptyCacheBytes, _ := json.Marshal(emptyCache)
+	stub.On("GetState", "emptykey").Return(emptyCacheBytes, nil)
+
+	_, err = c.GetLastModifier(ctx, "emptykey")
+	assert.EqualError(t, err, "The cache emptykey has no access log entries!", "should error without access log entries")
+}
+
+func TestGetAuditTrail(t *testing.T) {
+	//a cache with two recorded modifications in its access log
+	auditedGeoCache := new(GeoCache)
+	auditedGeoCache.Name = "audited cache"
+	auditedGeoCache.AccessLog = append(auditedGeoCache.AccessLog, AccessEntry{ClientId: "clientA", Operation: "CreateGeoCache", Timestamp: "1970-01-12T13:46:40Z"})
+	auditedGeoCache.AccessLog = append(auditedGeoCache.AccessLog, AccessEntry{ClientId: "clientB", Operation: "UpdateGeoCache", Timestamp: "1970-01-12T14:46:40Z"})
+	auditedGeoCacheBytes, _ := json.Marshal(auditedGeoCache)
+
+	//the admin organization should get the entries in chronological order
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+	stub.On("GetState", "auditedkey").Return(auditedGeoCacheBytes, nil)
+
+	trail, err := c.GetAuditTrail(ctx, "auditedkey")
+	assert.Nil(t, err, "should not return error for an admin caller")
+	assert.Len(t, trail, 2, "should return both access entries")
+	assert.Equal(t, "CreateGeoCache", trail[0].Operation)
+	assert.Equal(t, "UpdateGeoCache", trail[1].Operation)
+
+	//a caller outside the admin organization should be rejected
+	otherCtx, _ := configureStub()
+	trail, err = c.GetAuditTrail(otherCtx, "auditedkey")
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error for a non admin caller")
+	assert.Nil(t, trail, "should not return the access log for a non admin caller")
+}
+
+func TestGetCachesVisitedByUser(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a successful visit also writes the index entry
+	err := c.AddVisitorToGeoCache(ctx, *u, "geoCachekey", 6, 6, "")
+	assert.Nil(t, err, "should not return error when the visit succeeds")
+
+	expectedIndexKey, _ := shim.CreateCompositeKey(visitorIndexName, []string{"123", "geoCachekey"})
+	stub.AssertCalled(t, "PutState", expectedIndexKey, []byte{0x00})
+
+	//the index holds two visits of the user
+	keyA, _ := shim.CreateCompositeKey(visitorIndexName, []string{"123", "cacheA"})
+	keyB, _ := shim.CreateCompositeKey(visitorIndexName, []string{"123", "cacheB"})
+	stub.On("GetStateByPartialCompositeKey", visitorIndexName, []string{"123"}).Return(configureIterator(
+		&queryresult.KV{Key: keyA},
+		&queryresult.KV{Key: keyB},
+	), nil)
+
+	cacheIds, err := c.GetCachesVisitedByUser(ctx, *u)
+	assert.Nil(t, err, "should not return error when the index can be read")
+	assert.Equal(t, []string{"cacheA", "cacheB"}, cacheIds, "should return the cache ids from the index keys")
+}
+
+func TestGetUnvisitedCaches(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//two visited caches and one that nobody found yet
+	visitedOne := new(GeoCache)
+	visitedOne.Name = "visited one"
+	visitedOne.Visitors = []User{*u}
+	visitedOne.Published = true
+	visitedOneBytes, _ := json.Marshal(visitedOne)
+
+	visitedTwo := new(GeoCache)
+	visitedTwo.Name = "visited two"
+	visitedTwo.Visitors = []User{*u}
+	visitedTwo.Published = true
+	visitedTwoBytes, _ := json.Marshal(visitedTwo)
+
+	unvisited := new(GeoCache)
+	unvisited.Name = "unvisited"
+	unvisited.Owner = User{Id: "hashed", Name: "TestUser", Salt: "storedsalt"}
+	unvisited.Published = true
+	unvisitedBytes, _ := json.Marshal(unvisited)
+
+	//an unvisited draft must stay hidden from the listing
+	draft := new(GeoCache)
+	draft.Name = "draft"
+	draftBytes, _ := json.Marshal(draft)
+
+	//an account record has no visitors either, but it must not show up in the listing
+	account := UserAccount{Name: "TestUser", RegisteredAt: 100}
+	accountBytes, _ := json.Marshal(account)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: visitedOneBytes},
+		&queryresult.KV{Key: "cache2", Value: unvisitedBytes},
+		&queryresult.KV{Key: "user_123", Value: accountBytes},
+		&queryresult.KV{Key: "cache3", Value: visitedTwoBytes},
+		&queryresult.KV{Key: "cache4", Value: draftBytes},
+	), nil)
+
+	//only the cache with the empty visitors list should be returned
+	caches, err := c.GetUnvisitedCaches(ctx)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, caches, 1, "should only return the unvisited cache")
+	assert.Equal(t, "unvisited", caches[0].Name)
+	assert.Equal(t, "", caches[0].Owner.Salt, "should not leak the stored salt")
+}
+
+func TestIterateValidCaches(t *testing.T) {
+	ctx, stub := configureStub()
+
+	validCache := new(GeoCache)
+	validCache.Name = "valid"
+	validCacheBytes, _ := json.Marshal(validCache)
+
+	//account, held trackable and config records unmarshal cleanly, only their key reveals them
+	account := UserAccount{Name: "TestUser", RegisteredAt: 100}
+	accountBytes, _ := json.Marshal(account)
+
+	heldTrackable := Trackable{Id: "testId", Value: "testValue"}
+	heldTrackableBytes, _ := json.Marshal(heldTrackable)
+
+	//a mixed world state with a corrupt value and the non-cache namespaces between two caches
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: validCacheBytes},
+		&queryresult.KV{Key: "corrupt", Value: []byte("not a cache")},
+		&queryresult.KV{Key: "user_123", Value: accountBytes},
+		&queryresult.KV{Key: "held_123", Value: heldTrackableBytes},
+		&queryresult.KV{Key: "config_reportsEnabled", Value: []byte("true")},
+		&queryresult.KV{Key: "cache2", Value: validCacheBytes},
+	), nil)
+
+	//only the values that can be read as a cache reach the callback
+	seen := []string{}
+	err := iterateValidCaches(ctx, func(id string, geoCache *GeoCache) error {
+		seen = append(seen, id)
+		return nil
+	})
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Equal(t, []string{"cache1", "cache2"}, seen, "should skip the corrupt value and the non-cache namespaces")
+}
+
+func TestGetCacheSpread(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//three caches with midpoints (20,20), (23,24) and (26,28), the farthest pair is 10 apart
+	first := new(GeoCache)
+	first.Name = "first"
+	first.XcoordRange = [2]int{19, 21}
+	first.YcoordRange = [2]int{19, 21}
+	firstBytes, _ := json.Marshal(first)
+
+	near := new(GeoCache)
+	near.Name = "near"
+	near.XcoordRange = [2]int{22, 24}
+	near.YcoordRange = [2]int{23, 25}
+	nearBytes, _ := json.Marshal(near)
+
+	far := new(GeoCache)
+	far.Name = "far"
+	far.XcoordRange = [2]int{25, 27}
+	far.YcoordRange = [2]int{27, 29}
+	farBytes, _ := json.Marshal(far)
+
+	//a held trackable record would sit at the (0,0) midpoint and inflate the spread
+	heldTrackable := Trackable{Id: "testId", Value: "testValue"}
+	heldTrackableBytes, _ := json.Marshal(heldTrackable)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: firstBytes},
+		&queryresult.KV{Key: "held_123", Value: heldTrackableBytes},
+		&queryresult.KV{Key: "cache2", Value: nearBytes},
+		&queryresult.KV{Key: "cache3", Value: farBytes},
+	), nil)
+
+	spread, err := c.GetCacheSpread(ctx)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.InDelta(t, 10, spread, 0.000001, "should return the distance of the farthest pair")
+}
+
+func TestGetCacheSpreadTooFewCaches(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	lonely := new(GeoCache)
+	lonely.Name = "lonely"
+	lonelyBytes, _ := json.Marshal(lonely)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: lonelyBytes},
+	), nil)
+
+	//a single cache has no pairwise distance
+	_, err := c.GetCacheSpread(ctx)
+	assert.EqualError(t, err, "There are not enough caches to compute the spread!", "should error with fewer than two caches")
+}
+
+func TestFindNearestCache(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//three caches at different distances from the query point
+	nearCache := new(GeoCache)
+	nearCache.Name = "near cache"
+	nearCache.XcoordRange = [2]int{2, 4}
+	nearCache.YcoordRange = [2]int{0, 0}
+	nearCache.Owner = User{Id: "hashed", Name: "TestUser", Salt: "storedsalt"}
+	nearCache.Published = true
+	nearCacheBytes, _ := json.Marshal(nearCache)
+
+	midCache := new(GeoCache)
+	midCache.Name = "mid cache"
+	midCache.XcoordRange = [2]int{10, 12}
+	midCache.YcoordRange = [2]int{0, 0}
+	midCache.Published = true
+	midCacheBytes, _ := json.Marshal(midCache)
+
+	farCache := new(GeoCache)
+	farCache.Name = "far cache"
+	farCache.XcoordRange = [2]int{50, 60}
+	farCache.YcoordRange = [2]int{50, 60}
+	farCache.Published = true
+	farCacheBytes, _ := json.Marshal(farCache)
+
+	//an unpublished draft at the query point would be a location oracle
+	draftCache := new(GeoCache)
+	draftCache.Name = "draft cache"
+	draftCache.XcoordRange = [2]int{0, 0}
+	draftCache.YcoordRange = [2]int{0, 0}
+	draftCacheBytes, _ := json.Marshal(draftCache)
+
+	//an account record would sit at the (0,0) midpoint, but it is not a cache
+	account := UserAccount{Name: "TestUser", RegisteredAt: 100}
+	accountBytes, _ := json.Marshal(account)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: farCacheBytes},
+		&queryresult.KV{Key: "user_123", Value: accountBytes},
+		&queryresult.KV{Key: "cache2", Value: nearCacheBytes},
+		&queryresult.KV{Key: "cache4", Value: draftCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: midCacheBytes},
+	), nil).Once()
+
+	nearest, distance, err := c.FindNearestCache(ctx, 0, 0)
+	assert.Nil(t, err, "should not return error when the world state holds caches")
+	assert.Equal(t, "near cache", nearest.Name, "should skip the draft and return the closest published cache")
+	assert.Equal(t, 3.0, distance, "should return the distance to the range center")
+	assert.Equal(t, "", nearest.Owner.Salt, "should not leak the stored salt")
+
+	//an empty ledger gets a clear error
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
+
+	nearest, _, err = c.FindNearestCache(ctx, 0, 0)
+	assert.EqualError(t, err, "There are no caches in the world state!", "should error on an empty ledger")
+	assert.Nil(t, nearest, "should not return a cache on an empty ledger")
+}
+
+func TestGetNearestOwnedCache(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	//two caches of the test user at different distances from the origin, the stored id is myHash("123" + "123")
+	hashedOwner := User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+
+	nearCache := new(GeoCache)
+	nearCache.Name = "near cache"
+	nearCache.Owner = hashedOwner
+	nearCache.XcoordRange = [2]int{2, 4}
+	nearCache.YcoordRange = [2]int{3, 5}
+	nearCacheBytes, _ := json.Marshal(nearCache)
+
+	farCache := new(GeoCache)
+	farCache.Name = "far cache"
+	farCache.Owner = hashedOwner
+	farCache.XcoordRange = [2]int{50, 60}
+	farCache.YcoordRange = [2]int{50, 60}
+	farCacheBytes, _ := json.Marshal(farCache)
+
+	//a cache of somebody else right on the point must not win
+	foreignCache := new(GeoCache)
+	foreignCache.Name = "foreign cache"
+	foreignCache.Owner = User{Id: "unrelateddigest", Name: "SomeoneElse", Salt: "456"}
+	foreignCacheBytes, _ := json.Marshal(foreignCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: farCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: nearCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: foreignCacheBytes},
+	), nil)
+
+	nearest, err := c.GetNearestOwnedCache(ctx, *owner, 0, 0)
+	assert.Nil(t, err, "should not return error when the user owns caches")
+	assert.Equal(t, "near cache", nearest.Name, "should return the closest cache of the caller")
+
+	//a user without caches gets a not found error
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	stub.ExpectedCalls = nil
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: nearCacheBytes},
+	), nil)
+
+	_, err = c.GetNearestOwnedCache(ctx, *stranger, 0, 0)
+	assert.EqualError(t, err, "The user Stranger does not own any caches!", "should error when the user owns no caches")
+}
+
+func TestQueryGeoCachesByOwner(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	//one cache of the test user and one of somebody else, the stored id is myHash("123" + "123")
+	ownedCache := new(GeoCache)
+	ownedCache.Name = "owned cache"
+	ownedCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	ownedCacheBytes, _ := json.Marshal(ownedCache)
+
+	foreignCache := new(GeoCache)
+	foreignCache.Name = "foreign cache"
+	foreignCache.Owner = User{Id: "unrelateddigest", Name: "SomeoneElse", Salt: "456"}
+	foreignCacheBytes, _ := json.Marshal(foreignCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: ownedCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: foreignCacheBytes},
+	), nil)
+
+	//the owner only gets their own cache and the stored salt is stripped
+	caches, err := c.QueryGeoCachesByOwner(ctx, *owner)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, caches, 1, "should only return the cache of the caller")
+	assert.Equal(t, "owned cache", caches[0].Name)
+	assert.Equal(t, "", caches[0].Owner.Salt, "should strip the stored salt")
+
+	//a non owner gets an empty result instead of an error
+	stub.ExpectedCalls = nil
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: ownedCacheBytes},
+	), nil)
+
+	caches, err = c.QueryGeoCachesByOwner(ctx, *stranger)
+	assert.Nil(t, err, "should not return error for a non owner caller")
+	assert.Len(t, caches, 0, "should return no caches for a non owner")
+}
+
+func TestQueryGeoCachesInBox(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	cacheOne := new(GeoCache)
+	cacheOne.Name = "cache one"
+	cacheOne.Owner = User{Id: "hashed", Name: "TestUser", Salt: "storedsalt"}
+	cacheOneBytes, _ := json.Marshal(cacheOne)
+
+	cacheTwo := new(GeoCache)
+	cacheTwo.Name = "cache two"
+	cacheTwoBytes, _ := json.Marshal(cacheTwo)
+
+	//the selector has to be well formed JSON that matches on the scalar bound fields and
+	//keeps the drafts out; array elements like "XcoordRange.0" can not be addressed in
+	//the selector syntax
+	stub.On("GetQueryResult", mock.MatchedBy(func(query string) bool {
+		parsed := make(map[string]map[string]interface{})
+		if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+			return false
+		}
+		selector, hasSelector := parsed["selector"]
+		if !hasSelector {
+			return false
+		}
+		for _, field := range []string{"MinX", "MaxX", "MinY", "MaxY"} {
+			if _, ok := selector[field]; !ok {
+				return false
+			}
+		}
+		return selector["Published"] == true
+	})).Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: cacheOneBytes},
+		&queryresult.KV{Key: "cache2", Value: cacheTwoBytes},
+	), nil)
+
+	//an inverted box is rejected before touching the state database
+	_, err := c.QueryGeoCachesInBox(ctx, 20, 0, 0, 20)
+	assert.EqualError(t, err, "The bounding box is invalid!", "should error on an inverted box")
+
+	caches, err := c.QueryGeoCachesInBox(ctx, 0, 20, 0, 20)
+	assert.Nil(t, err, "should not return error when the rich query succeeds")
+	assert.Len(t, caches, 2, "should return both caches of the query result")
+	assert.Equal(t, "cache one", caches[0].Name)
+	assert.Equal(t, "cache two", caches[1].Name)
+	assert.Equal(t, "", caches[0].Owner.Salt, "should not leak the stored salt")
+}
+
+func TestGetGeoCachesInBoxCreatedBetween(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//one cache matching both filters, one only the box and one only the window
+	matching := new(GeoCache)
+	matching.Name = "matching"
+	matching.XcoordRange = [2]int{5, 10}
+	matching.YcoordRange = [2]int{5, 10}
+	matching.CreatedAt = "1970-01-12T13:46:40Z"
+	matching.Owner = User{Id: "hashed", Name: "TestUser", Salt: "storedsalt"}
+	matching.Published = true
+	matchingBytes, _ := json.Marshal(matching)
+
+	outsideWindow := new(GeoCache)
+	outsideWindow.Name = "outside window"
+	outsideWindow.XcoordRange = [2]int{5, 10}
+	outsideWindow.YcoordRange = [2]int{5, 10}
+	outsideWindow.CreatedAt = "1980-01-12T13:46:40Z"
+	outsideWindow.Published = true
+	outsideWindowBytes, _ := json.Marshal(outsideWindow)
+
+	outsideBox := new(GeoCache)
+	outsideBox.Name = "outside box"
+	outsideBox.XcoordRange = [2]int{50, 60}
+	outsideBox.YcoordRange = [2]int{5, 10}
+	outsideBox.CreatedAt = "1970-01-12T13:46:40Z"
+	outsideBox.Published = true
+	outsideBoxBytes, _ := json.Marshal(outsideBox)
+
+	//a draft matching both filters must stay hidden from the listing
+	draft := new(GeoCache)
+	draft.Name = "draft"
+	draft.XcoordRange = [2]int{5, 10}
+	draft.YcoordRange = [2]int{5, 10}
+	draft.CreatedAt = "1970-01-12T13:46:40Z"
+	draftBytes, _ := json.Marshal(draft)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: matchingBytes},
+		&queryresult.KV{Key: "cache2", Value: outsideWindowBytes},
+		&queryresult.KV{Key: "cache3", Value: outsideBoxBytes},
+		&queryresult.KV{Key: "cache4", Value: draftBytes},
+	), nil)
+
+	//an inverted box or window is rejected before touching the world state
+	_, err := c.GetGeoCachesInBoxCreatedBetween(ctx, 20, 0, 0, 20, 0, 2000000)
+	assert.EqualError(t, err, "The bounding box is invalid!", "should error on an inverted box")
+
+	_, err = c.GetGeoCachesInBoxCreatedBetween(ctx, 0, 0, 20, 20, 2000000, 0)
+	assert.EqualError(t, err, "The time window is invalid!", "should error on an inverted window")
+
+	//only the cache inside both the box and the window should be returned
+	caches, err := c.GetGeoCachesInBoxCreatedBetween(ctx, 0, 0, 20, 20, 0, 2000000)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, caches, 1, "should only return the cache matching both filters")
+	assert.Equal(t, "matching", caches[0].Name)
+	assert.Equal(t, "", caches[0].Owner.Salt, "should not leak the stored salt")
+}
+
+func TestGetGeoCachesIntersecting(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//a single cache covering the 5-10 square
+	cache := new(GeoCache)
+	cache.Name = "viewport cache"
+	cache.XcoordRange = [2]int{5, 10}
+	cache.YcoordRange = [2]int{5, 10}
+	cache.Owner = User{Id: "hashed", Name: "TestUser", Salt: "storedsalt"}
+	cache.Published = true
+	cacheBytes, _ := json.Marshal(cache)
+
+	//a draft in the same square must not show up on the public map
+	draft := new(GeoCache)
+	draft.Name = "draft cache"
+	draft.XcoordRange = [2]int{5, 10}
+	draft.YcoordRange = [2]int{5, 10}
+	draftBytes, _ := json.Marshal(draft)
+
+	//an inverted viewport is rejected before touching the world state
+	_, err := c.GetGeoCachesIntersecting(ctx, [2]int{20, 0}, [2]int{0, 20})
+	assert.EqualError(t, err, "The viewport is invalid!", "should error on an inverted viewport")
+
+	tests := []struct {
+		name    string
+		viewX   [2]int
+		viewY   [2]int
+		matches int
+	}{
+		{"cache fully inside the viewport", [2]int{0, 20}, [2]int{0, 20}, 1},
+		{"viewport fully inside the cache", [2]int{6, 8}, [2]int{6, 8}, 1},
+		{"partial overlap", [2]int{8, 20}, [2]int{8, 20}, 1},
+		{"touching edges count as overlap", [2]int{10, 20}, [2]int{10, 20}, 1},
+		{"disjoint on the X axis", [2]int{11, 20}, [2]int{5, 10}, 0},
+		{"disjoint on the Y axis", [2]int{5, 10}, [2]int{11, 20}, 0},
+		{"disjoint on both axes", [2]int{11, 20}, [2]int{11, 20}, 0},
+	}
+
+	for _, tt := range tests {
+		//the mocked iterator is exhausted after one pass, so every case gets a fresh one
+		ctx, stub = configureStub()
+		stub.On("GetStateByRange", "", "").Return(configureIterator(
+			&queryresult.KV{Key: "cache1", Value: cacheBytes},
+			&queryresult.KV{Key: "cache2", Value: draftBytes},
+		), nil)
+
+		caches, err := c.GetGeoCachesIntersecting(ctx, tt.viewX, tt.viewY)
+		assert.Nil(t, err, tt.name)
+		assert.Len(t, caches, tt.matches, tt.name)
+
+		if tt.matches > 0 {
+			assert.Equal(t, "", caches[0].Owner.Salt, "should not leak the stored salt")
+		}
+	}
+}
+
+func TestMidpointBetweenCaches(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//two caches on the equator, a quarter of the globe apart
+	equatorCache := new(GeoCache)
+	equatorCache.Name = "equator"
+	equatorCache.Latitude = 0
+	equatorCache.Longitude = 10
+	equatorCacheBytes, _ := json.Marshal(equatorCache)
+	stub.On("GetState", "equatorkey").Return(equatorCacheBytes, nil)
+
+	eastCache := new(GeoCache)
+	eastCache.Name = "east"
+	eastCache.Latitude = 0
+	eastCache.Longitude = 90
+	eastCacheBytes, _ := json.Marshal(eastCache)
+	stub.On("GetState", "eastkey").Return(eastCacheBytes, nil)
+
+	northCache := new(GeoCache)
+	northCache.Name = "north"
+	northCache.Latitude = 90
+	northCache.Longitude = 0
+	northCacheBytes, _ := json.Marshal(northCache)
+	stub.On("GetState", "northkey").Return(northCacheBytes, nil)
+
+	//the midpoint of two equator points is halfway along the equator
+	lat, lon, err := c.MidpointBetweenCaches(ctx, "equatorkey", "eastkey")
+	assert.Nil(t, err, "should not return error when both caches have GPS coordinates")
+	assert.InDelta(t, 0, lat, 0.001, "the midpoint latitude should stay on the equator")
+	assert.InDelta(t, 50, lon, 0.001, "the midpoint longitude should be halfway")
+
+	//the midpoint between the equator and the pole is at 45 degrees
+	lat, lon, err = c.MidpointBetweenCaches(ctx, "equatorkey", "northkey")
+	assert.Nil(t, err, "should not return error when both caches have GPS coordinates")
+	assert.InDelta(t, 45, lat, 0.001, "the midpoint latitude should be halfway to the pole")
+	assert.InDelta(t, 10, lon, 0.001, "the midpoint longitude should not change")
+
+	//geoCachekey has no GPS coordinates, so the midpoint can not be computed
+	_, _, err = c.MidpointBetweenCaches(ctx, "equatorkey", "geoCachekey")
+	assert.EqualError(t, err, "The cache geoCachekey has no GPS coordinates!", "should error when a cache lacks GPS coordinates")
+}
+
+func TestGetUserFindStreak(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//finds on three consecutive days, then a gap, then a single day
+	streakCache := new(GeoCache)
+	streakCache.Name = "streak cache"
+	streakCache.VisitLog = []Visit{
+		{User: *u, Timestamp: "2021-05-01T10:00:00Z"},
+		{User: *u, Timestamp: "2021-05-02T09:00:00Z"},
+		{User: *u, Timestamp: "2021-05-06T12:00:00Z"},
+	}
+	streakCacheBytes, _ := json.Marshal(streakCache)
+
+	//the middle day of the streak was logged on another cache; the truncated stored
+	//timestamp must be skipped instead of panicking on the day slice
+	otherCache := new(GeoCache)
+	otherCache.Name = "other cache"
+	otherCache.VisitLog = []Visit{
+		{User: *u, Timestamp: "2021-05-03T18:00:00Z"},
+		{User: User{Id: "789"}, Timestamp: "2021-05-04T18:00:00Z"},
+		{User: *u, Timestamp: "2021-05"},
+	}
+	otherCacheBytes, _ := json.Marshal(otherCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: streakCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: otherCacheBytes},
+	), nil)
+
+	//the runs are 01-02-03 and 06, the other user's find does not count
+	streak, err := c.GetUserFindStreak(ctx, *u)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Equal(t, 3, streak, "should return the longest run of consecutive days")
+}
+
+func TestGetUserAverageFoundDifficulty(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//an easy cache the user found
+	easyCache := new(GeoCache)
+	easyCache.Name = "easy cache"
+	easyCache.Difficulty = 1
+	easyCache.VisitLog = []Visit{{User: *u, Timestamp: "2021-05-01T10:00:00Z"}}
+	easyCacheBytes, _ := json.Marshal(easyCache)
+
+	//a hard cache the user found
+	hardCache := new(GeoCache)
+	hardCache.Name = "hard cache"
+	hardCache.Difficulty = 4
+	hardCache.VisitLog = []Visit{{User: *u, Timestamp: "2021-05-02T10:00:00Z"}}
+	hardCacheBytes, _ := json.Marshal(hardCache)
+
+	//a rated cache found only by someone else
+	foreignCache := new(GeoCache)
+	foreignCache.Name = "foreign cache"
+	foreignCache.Difficulty = 5
+	foreignCache.VisitLog = []Visit{{User: User{Id: "789"}, Timestamp: "2021-05-03T10:00:00Z"}}
+	foreignCacheBytes, _ := json.Marshal(foreignCache)
+
+	//an unrated cache the user found, it must not drag the average down
+	unratedCache := new(GeoCache)
+	unratedCache.Name = "unrated cache"
+	unratedCache.VisitLog = []Visit{{User: *u, Timestamp: "2021-05-04T10:00:00Z"}}
+	unratedCacheBytes, _ := json.Marshal(unratedCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: easyCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: hardCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: foreignCacheBytes},
+		&queryresult.KV{Key: "cache4", Value: unratedCacheBytes},
+	), nil)
+
+	average, err := c.GetUserAverageFoundDifficulty(ctx, *u)
+	assert.Nil(t, err, "should not return error when the user has rated finds")
+	assert.Equal(t, 2.5, average, "should average the difficulty of the rated found caches only")
+
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	_, err = c.GetUserAverageFoundDifficulty(ctx, *stranger)
+	assert.EqualError(t, err, "The user 456 has not found any rated caches!", "should error when the user has no rated finds")
+}
+
+func TestGetUserProfile(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a cache owned by the user, the stored id is myHash("123" + "123")
+	ownedCache := new(GeoCache)
+	ownedCache.Name = "owned"
+	ownedCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	ownedCacheBytes, _ := json.Marshal(ownedCache)
+
+	//a cache the user visited twice, reported and favorited
+	activeCache := new(GeoCache)
+	activeCache.Name = "active"
+	activeCache.Owner = User{Id: "otherhash", Name: "Other", Salt: "xyz"}
+	activeCache.Visitors = []User{*u}
+	activeCache.VisitLog = []Visit{
+		{User: *u, Xcoord: 6, Ycoord: 6, Timestamp: "1970-01-12T13:46:40Z"},
+		{User: *u, Xcoord: 7, Ycoord: 7, Timestamp: "1970-01-12T13:46:41Z"},
+	}
+	activeCache.Reports = []Report{{Id: "r1", Message: "broken", Notifier: *u}}
+	activeCache.FavoritedBy = []User{*u}
+	activeCacheBytes, _ := json.Marshal(activeCache)
+
+	//a cache the user never touched
+	untouchedCache := new(GeoCache)
+	untouchedCache.Name = "untouched"
+	untouchedCache.Owner = User{Id: "otherhash", Name: "Other", Salt: "xyz"}
+	untouchedCacheBytes, _ := json.Marshal(untouchedCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: ownedCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: activeCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: untouchedCacheBytes},
+	), nil)
+
+	profile, err := c.GetUserProfile(ctx, *u)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Equal(t, 1, profile.OwnedCaches, "should count the owned cache")
+	assert.Equal(t, 1, profile.VisitedCaches, "should count the visited cache once")
+	assert.Equal(t, 2, profile.FoundCaches, "should count every check-in as a find")
+	assert.Equal(t, 1, profile.ReportsFiled, "should count the filed report")
+	assert.Equal(t, 1, profile.Favorites, "should count the favorited cache")
+}
+
+func TestAddVisitorToGeoCacheCooldown(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//the first visit of the user succeeds
+	err = c.AddVisitorToGeoCache(ctx, *u, "geoCachekey", 6, 6, "")
+	assert.Nil(t, err, "should not return error on the first visit")
+
+	//a cache the user checked in on at the mocked transaction time
+	visitedCache := new(GeoCache)
+	visitedCache.Name = "visited cache"
+	visitedCache.XcoordRange = [2]int{5, 10}
+	visitedCache.YcoordRange = [2]int{5, 10}
+	visitedCache.Visitors = []User{*u}
+	visitedCache.VisitLog = []Visit{{User: *u, Xcoord: 6, Ycoord: 6, Timestamp: "1970-01-12T13:46:40Z"}}
+	visitedCacheBytes, _ := json.Marshal(visitedCache)
+	stub.On("GetState", "visitedkey").Return(visitedCacheBytes, nil)
+
+	//an immediate second check-in is rejected by the cooldown
+	err = c.AddVisitorToGeoCache(ctx, *u, "visitedkey", 6, 6, "")
+	assert.EqualError(t, err, "The user TestUser has to wait before checking in again!", "should error within the cooldown")
+
+	//the range check still comes first
+	err = c.AddVisitorToGeoCache(ctx, *u, "visitedkey", 4, 4, "")
+	assert.EqualError(t, err, "You are not in the cache's location range!", "should reject out of range visits before the cooldown check")
+
+	//a cache whose last check-in of the user is a full cooldown in the past
+	cooledCache := new(GeoCache)
+	cooledCache.Name = "cooled cache"
+	cooledCache.XcoordRange = [2]int{5, 10}
+	cooledCache.YcoordRange = [2]int{5, 10}
+	cooledCache.Visitors = []User{*u}
+	cooledCache.VisitLog = []Visit{{User: *u, Xcoord: 6, Ycoord: 6, Timestamp: formatTimestamp(1000000 - visitCooldownSeconds)}}
+	cooledCacheBytes, _ := json.Marshal(cooledCache)
+	stub.On("GetState", "cooledkey").Return(cooledCacheBytes, nil)
+
+	//a check-in after the cooldown succeeds and only extends the visit log
+	err = c.AddVisitorToGeoCache(ctx, *u, "cooledkey", 6, 6, "")
+	assert.Nil(t, err, "should not return error after the cooldown")
+	stub.AssertCalled(t, "PutState", "cooledkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.Visitors) == 1 && len(storedCache.VisitLog) == 2
+	}))
+}
+
+func TestAddVisitorToGeoCacheEvent(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a successful visit emits the find feed event with the check-in details
+	err = c.AddVisitorToGeoCache(ctx, *u, "geoCachekey", 6, 7, "")
+	assert.Nil(t, err, "should not return error when the visit succeeds")
+	stub.AssertCalled(t, "SetEvent", "VisitorAdded", mock.MatchedBy(func(payload []byte) bool {
+		event := new(VisitorAddedEvent)
+		json.Unmarshal(payload, event)
+		return event.CacheId == "geoCachekey" && event.VisitorName == "TestUser" &&
+			event.Xcoord == 6 && event.Ycoord == 7
+	}))
+
+	//a rejected visit must not emit the event
+	ctx2, stub2 := configureStub()
+	err = c.AddVisitorToGeoCache(ctx2, *u, "geoCachekey", 4, 4, "")
+	assert.NotNil(t, err, "should return error when the visitor is out of range")
+	stub2.AssertNotCalled(t, "SetEvent", "VisitorAdded", mock.AnythingOfType("[]uint8"))
+}
+
+func TestAddVisitorToGeoCacheVerified(t *testing.T) {
+	var err error
+
+	//the caller's certificate carries the verified visitor attribute
+	ctx, stub := configureVerifiedStub()
+	c := new(GeoCacheContract)
+
+	verifiedUser := new(User)
+	verifiedUser.Id = "123"
+	verifiedUser.Name = "Verified"
+
+	err = c.AddVisitorToGeoCache(ctx, *verifiedUser, "geoCachekey", 6, 6, "")
+	assert.Nil(t, err, "should not return error when a verified user visits")
+	stub.AssertCalled(t, "PutState", "geoCachekey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		if len(storedCache.VerifiedVisitors) != 1 {
+			return false
+		}
+		//the verified entry is salted and hashed, so the raw id is not stored
+		entry := storedCache.VerifiedVisitors[0]
+		return entry.Id == myHash("123"+entry.Salt)
+	}))
+
+	//an unverified user only lands in the plain visitors list
+	ctx2, stub2 := configureStub()
+	unverifiedUser := new(User)
+	unverifiedUser.Id = "123"
+	unverifiedUser.Name = "TestUser"
+
+	err = c.AddVisitorToGeoCache(ctx2, *unverifiedUser, "geoCachekey", 6, 6, "")
+	assert.Nil(t, err, "should not return error when an unverified user visits")
+	stub2.AssertCalled(t, "PutState", "geoCachekey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.VerifiedVisitors) == 0 && len(storedCache.Visitors) == 1
+	}))
+}
+
+func TestCreateGeoCacheEvent(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a successful create emits an event with the new cache, without the salt
+	trackable, err := c.CreateGeoCache(ctx, *u, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "travel to every forest", "", "")
+	assert.Nil(t, err)
+
+	//the generated trackable is handed back to the caller
+	assert.NotNil(t, trackable, "should return the generated trackable")
+	assert.Equal(t, "asd", trackable.Value, "should carry the given trackable value")
+	assert.Equal(t, "travel to every forest", trackable.Mission, "should carry the given mission")
+	assert.NotEqual(t, "", trackable.Id, "should carry the generated trackable id")
+	stub.AssertCalled(t, "SetEvent", "GeoCacheCreated", mock.MatchedBy(func(payload []byte) bool {
+		eventCache := new(GeoCache)
+		json.Unmarshal(payload, eventCache)
+		return eventCache.Id == "missingkey" && eventCache.Owner.Salt == "" && eventCache.Trackable.Mission == "travel to every forest"
+	}))
+
+	//a payload that can not be marshalled surfaces as an error
+	err = emitEvent(ctx, "GeoCacheCreated", math.Inf(1))
+	assert.NotNil(t, err, "should return error when the payload can not be marshalled")
+}
+
+func TestOwnerCheckIgnoresClientSalt(t *testing.T) {
+	var err error
+
+	ctx, _ := configureStub()
+	c := new(GeoCacheContract)
+
+	//the claimed salt is bogus, only the stored cache salt may be used
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+	u.Salt = "forgedsalt"
+
+	//the owner check still passes, bc the stored salt hashes the id to the stored digest
+	err = c.UpdateGeoCache(ctx, *u, "geoCachekey", "new value", "newDescription", 0)
+	assert.Nil(t, err, "should not return error when the real owner sends a bogus salt")
+
+	//a non owner can not get in with a forged salt either
+	other := new(User)
+	other.Id = "789"
+	other.Name = "Other"
+	other.Salt = "123"
+
+	err = c.UpdateGeoCache(ctx, *other, "geoCachekey", "new value", "newDescription", 0)
+	assert.EqualError(t, err, "Only the owner can update a cache!", "should error when a non owner sends a forged salt")
+}
+
+func TestLogMaintenance(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	other := new(User)
+	other.Id = "789"
+	other.Name = "Other"
+
+	//a flagged cache of the owner, the stored id is myHash("123" + "123")
+	flaggedCache := new(GeoCache)
+	flaggedCache.Name = "flagged cache"
+	flaggedCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	flaggedCache.Status = statusNeedsMaintenance
+	flaggedCacheBytes, _ := json.Marshal(flaggedCache)
+	stub.On("GetState", "flaggedkey").Return(flaggedCacheBytes, nil)
+
+	//only the owner can log maintenance
+	err = c.LogMaintenance(ctx, *other, "flaggedkey", "replaced the logbook")
+	assert.EqualError(t, err, "Only the owner can update a cache!", "should error when not the owner logs maintenance")
+
+	//logging appends the entry and clears the maintenance flag
+	err = c.LogMaintenance(ctx, *owner, "flaggedkey", "replaced the logbook")
+	assert.Nil(t, err, "should not return error when the owner logs maintenance")
+	stub.AssertCalled(t, "PutState", "flaggedkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		if len(storedCache.MaintenanceLog) != 1 {
+			return false
+		}
+		entry := storedCache.MaintenanceLog[0]
+		return entry.Note == "replaced the logbook" && entry.Maintainer.Id == "123" &&
+			entry.Timestamp == "1970-01-12T13:46:40Z" && storedCache.Status == "" &&
+			storedCache.UpdatedAt == "1970-01-12T13:46:40Z"
+	}))
+}
+
+func TestAddCachePhoto(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	visitor := new(User)
+	visitor.Id = "123"
+	visitor.Name = "TestUser"
+
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	//a cache the visitor has logged a find on
+	photoCache := new(GeoCache)
+	photoCache.Name = "photo cache"
+	photoCache.VisitLog = []Visit{{User: *visitor, Timestamp: "1970-01-12T13:46:40Z"}}
+	photoCacheBytes, _ := json.Marshal(photoCache)
+	stub.On("GetState", "photokey").Return(photoCacheBytes, nil)
+
+	//the stranger has no registered account record either
+	var nilBytes []byte
+	stub.On("GetState", "user_456").Return(nilBytes, nil)
+
+	//an empty or non hex hash is rejected before anything else
+	err = c.AddCachePhoto(ctx, *visitor, "photokey", "")
+	assert.EqualError(t, err, "The photo hash must be a hex string!", "should error for an empty hash")
+
+	err = c.AddCachePhoto(ctx, *visitor, "photokey", "not-a-hash!")
+	assert.EqualError(t, err, "The photo hash must be a hex string!", "should error for a non hex hash")
+
+	//a caller who is neither the owner nor a visitor is rejected
+	err = c.AddCachePhoto(ctx, *stranger, "photokey", "abc123")
+	assert.EqualError(t, err, "Only the owner or a visitor can attach a photo!", "should error for an unrelated caller")
+
+	//a visitor's hash is appended to the cache
+	err = c.AddCachePhoto(ctx, *visitor, "photokey", "abc123")
+	assert.Nil(t, err, "should not return error for a visitor")
+	stub.AssertCalled(t, "PutState", "photokey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.PhotoHashes) == 1 && storedCache.PhotoHashes[0] == "abc123"
+	}))
+
+	//the owner of the fixture cache may attach a photo as well
+	err = c.AddCachePhoto(ctx, *visitor, "geoCachekey", "def456")
+	assert.Nil(t, err, "should not return error for the owner")
+}
+
+func TestGetCachePhotos(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//a cache with two attached photo hashes
+	galleryCache := new(GeoCache)
+	galleryCache.Name = "gallery cache"
+	galleryCache.PhotoHashes = []string{"abc123", "def456"}
+	galleryCacheBytes, _ := json.Marshal(galleryCache)
+	stub.On("GetState", "gallerykey").Return(galleryCacheBytes, nil)
+
+	photos, err := c.GetCachePhotos(ctx, "gallerykey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Equal(t, []string{"abc123", "def456"}, photos, "should return the attached photo hashes")
+
+	//a missing cache surfaces the usual error
+	_, err = c.GetCachePhotos(ctx, "missingkey")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error for a missing cache")
+}
+
+func TestSignLogbook(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	visitor := new(User)
+	visitor.Id = "123"
+	visitor.Name = "TestUser"
+
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	//a cache the visitor has logged a find on
+	signedCache := new(GeoCache)
+	signedCache.Name = "signed cache"
+	signedCache.VisitLog = []Visit{{User: *visitor, Timestamp: "1970-01-12T13:46:40Z"}}
+	signedCacheBytes, _ := json.Marshal(signedCache)
+	stub.On("GetState", "signedkey").Return(signedCacheBytes, nil)
+
+	//the stranger has no registered account record either
+	var nilBytes []byte
+	stub.On("GetState", "user_456").Return(nilBytes, nil)
+
+	//an empty note is rejected before anything else
+	err = c.SignLogbook(ctx, *visitor, "signedkey", "")
+	assert.EqualError(t, err, "The logbook text must not be empty!", "should error for an empty note")
+
+	//a non visitor can not sign the logbook
+	err = c.SignLogbook(ctx, *stranger, "signedkey", "TFTC!")
+	assert.EqualError(t, err, "Only a visitor can sign the logbook!", "should error for a non visitor")
+
+	//a visitor's note is appended with the transaction time
+	err = c.SignLogbook(ctx, *visitor, "signedkey", "TFTC!")
+	assert.Nil(t, err, "should not return error for a visitor")
+	stub.AssertCalled(t, "PutState", "signedkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.Logbook) == 1 && storedCache.Logbook[0].Author.Id == "123" &&
+			storedCache.Logbook[0].Text == "TFTC!" && storedCache.Logbook[0].Timestamp == "1970-01-12T13:46:40Z"
+	}))
+}
+
+func TestGetLogbook(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a cache with two logbook entries
+	loggedCache := new(GeoCache)
+	loggedCache.Name = "logged cache"
+	loggedCache.Logbook = []LogEntry{
+		{Author: *u, Text: "TFTC!", Timestamp: "1970-01-12T13:46:40Z"},
+		{Author: *u, Text: "came back with a friend", Timestamp: "1970-01-13T13:46:40Z"},
+	}
+	loggedCacheBytes, _ := json.Marshal(loggedCache)
+	stub.On("GetState", "loggedkey").Return(loggedCacheBytes, nil)
+
+	logbook, err := c.GetLogbook(ctx, "loggedkey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Len(t, logbook, 2, "should return both logbook entries")
+	assert.Equal(t, "TFTC!", logbook[0].Text)
+
+	//a missing cache surfaces the usual error
+	_, err = c.GetLogbook(ctx, "missingkey")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error for a missing cache")
+}
+
+func TestAwardFavoritePoint(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	visitor := new(User)
+	visitor.Id = "123"
+	visitor.Name = "TestUser"
+
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	//a cache the visitor has logged a find on
+	favoriteCache := new(GeoCache)
+	favoriteCache.Name = "favorite cache"
+	favoriteCache.VisitLog = []Visit{{User: *visitor, Timestamp: "1970-01-12T13:46:40Z"}}
+	favoriteCacheBytes, _ := json.Marshal(favoriteCache)
+	stub.On("GetState", "favoritekey").Return(favoriteCacheBytes, nil)
+
+	//the stranger has no registered account record either
+	var nilBytes []byte
+	stub.On("GetState", "user_456").Return(nilBytes, nil)
+
+	//a non visitor can not award a point
+	err = c.AwardFavoritePoint(ctx, *stranger, "favoritekey")
+	assert.EqualError(t, err, "Only a visitor can award a favorite point!", "should error for a non visitor")
+
+	//a visitor can award a single point
+	err = c.AwardFavoritePoint(ctx, *visitor, "favoritekey")
+	assert.Nil(t, err, "should not return error for a visitor")
+	stub.AssertCalled(t, "PutState", "favoritekey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.FavoritePoints == 1 && len(storedCache.FavoritedBy) == 1 &&
+			storedCache.FavoritedBy[0].Id == "123"
+	}))
+
+	//the second award of the same user is rejected
+	awardedCache := new(GeoCache)
+	awardedCache.Name = "awarded cache"
+	awardedCache.VisitLog = []Visit{{User: *visitor, Timestamp: "1970-01-12T13:46:40Z"}}
+	awardedCache.FavoritedBy = []User{*visitor}
+	awardedCache.FavoritePoints = 1
+	awardedCacheBytes, _ := json.Marshal(awardedCache)
+	stub.On("GetState", "awardedkey").Return(awardedCacheBytes, nil)
+
+	err = c.AwardFavoritePoint(ctx, *visitor, "awardedkey")
+	assert.EqualError(t, err, "The user TestUser has already awarded a favorite point!", "should error on a duplicate award")
+}
+
+func TestAddReview(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	visitor := new(User)
+	visitor.Id = "123"
+	visitor.Name = "TestUser"
+
+	//a cache the test user already visited
+	visitedCache := new(GeoCache)
+	visitedCache.Name = "visited cache"
+	visitedCache.Visitors = []User{*visitor}
+	visitedCacheBytes, _ := json.Marshal(visitedCache)
+	stub.On("GetState", "reviewedkey").Return(visitedCacheBytes, nil)
+
+	//the star value has to stay between 1 and 5
+	err = c.AddReview(ctx, *visitor, "reviewedkey", 0, "bad")
+	assert.EqualError(t, err, "The stars must be between 1 and 5!", "should error on too few stars")
+
+	err = c.AddReview(ctx, *visitor, "reviewedkey", 6, "great")
+	assert.EqualError(t, err, "The stars must be between 1 and 5!", "should error on too many stars")
+
+	//someone who never found the cache can not review it
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+	stub.On("GetState", "user_456").Return([]byte(nil), nil)
+
+	err = c.AddReview(ctx, *stranger, "reviewedkey", 4, "never found it")
+	assert.EqualError(t, err, "Only a visitor can review a cache!", "should error for a non visitor")
+
+	//a visitor's review is stored with the transaction time
+	err = c.AddReview(ctx, *visitor, "reviewedkey", 5, "lovely spot")
+	assert.Nil(t, err, "should not return error for a visitor")
+	stub.AssertCalled(t, "PutState", "reviewedkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		if len(storedCache.Reviews) != 1 {
+			return false
+		}
+		review := storedCache.Reviews[0]
+		return review.Stars == 5 && review.Comment == "lovely spot" &&
+			review.Reviewer.Id == "123" && review.Timestamp == "1970-01-12T13:46:40Z"
+	}))
+}
+
+func TestGetAverageRating(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a cache with two reviews averaging 4.5 stars
+	ratedCache := new(GeoCache)
+	ratedCache.Name = "rated cache"
+	ratedCache.Reviews = append(ratedCache.Reviews, Review{Reviewer: *u, Stars: 5})
+	ratedCache.Reviews = append(ratedCache.Reviews, Review{Reviewer: *u, Stars: 4})
+	ratedCacheBytes, _ := json.Marshal(ratedCache)
+	stub.On("GetState", "ratedkey").Return(ratedCacheBytes, nil)
+
+	average, err := c.GetAverageRating(ctx, "ratedkey")
+	assert.Nil(t, err, "should not return error when the cache has reviews")
+	assert.Equal(t, 4.5, average, "should return the average of the stars")
+
+	//the fixture cache has no reviews yet
+	_, err = c.GetAverageRating(ctx, "geoCachekey")
+	assert.EqualError(t, err, "The cache geoCachekey has no reviews!", "should error without reviews")
+}
+
+func TestAddPrerequisite(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	//two caches of the owner where the second already requires the first
+	hashedOwner := User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+
+	firstCache := new(GeoCache)
+	firstCache.Name = "first cache"
+	firstCache.Owner = hashedOwner
+	firstCacheBytes, _ := json.Marshal(firstCache)
+	stub.On("GetState", "firstkey").Return(firstCacheBytes, nil)
+
+	secondCache := new(GeoCache)
+	secondCache.Name = "second cache"
+	secondCache.Owner = hashedOwner
+	secondCache.Prerequisites = []string{"firstkey"}
+	secondCacheBytes, _ := json.Marshal(secondCache)
+	stub.On("GetState", "secondkey").Return(secondCacheBytes, nil)
+
+	//a cache can not require itself
+	err = c.AddPrerequisite(ctx, *owner, "firstkey", "firstkey")
+	assert.EqualError(t, err, "A cache can not require itself!", "should error on a self reference")
+
+	//the reverse edge would close a two cache loop
+	err = c.AddPrerequisite(ctx, *owner, "firstkey", "secondkey")
+	assert.EqualError(t, err, "The prerequisite would create a cycle!", "should error on a two cache cycle")
+
+	//only the owner can add a prerequisite
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	err = c.AddPrerequisite(ctx, *stranger, "secondkey", "firstkey")
+	assert.EqualError(t, err, "Only the owner can add a prerequisite!", "should error when not the owner adds")
+
+	//an acyclic requirement is stored on the cache
+	err = c.AddPrerequisite(ctx, *owner, "firstkey", "geoCachekey")
+	assert.Nil(t, err, "should not return error for an acyclic prerequisite")
+	stub.AssertCalled(t, "PutState", "firstkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.Prerequisites) == 1 && storedCache.Prerequisites[0] == "geoCachekey"
+	}))
+}
+
+func TestSetGeoCacheStatus(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	other := new(User)
+	other.Id = "789"
+	other.Name = "Other"
+
+	//an unknown status is rejected before touching the world state
+	err = c.SetGeoCacheStatus(ctx, *owner, "geoCachekey", "BROKEN")
+	assert.EqualError(t, err, "The status BROKEN is not valid!", "should error on an unknown status")
+
+	//only the owner can change the status
+	err = c.SetGeoCacheStatus(ctx, *other, "geoCachekey", statusDisabled)
+	assert.EqualError(t, err, "Only the owner can change the status of a cache!", "should error when not the owner sets the status")
+
+	//the owner can move the cache through every lifecycle state
+	for _, status := range []string{statusDisabled, statusArchived, statusActive} {
+		err = c.SetGeoCacheStatus(ctx, *owner, "geoCachekey", status)
+		assert.Nil(t, err, "should not return error when the owner sets the status")
+
+		expected := status
+		stub.AssertCalled(t, "PutState", "geoCachekey", mock.MatchedBy(func(bytes []byte) bool {
+			storedCache := new(GeoCache)
+			json.Unmarshal(bytes, storedCache)
+			return storedCache.Status == expected
+		}))
+	}
+}
+
+func TestAddVisitorToGeoCacheDisabled(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a disabled cache in the user's range
+	disabledCache := new(GeoCache)
+	disabledCache.Name = "disabled cache"
+	disabledCache.XcoordRange = [2]int{5, 10}
+	disabledCache.YcoordRange = [2]int{5, 10}
+	disabledCache.Status = statusDisabled
+	disabledCacheBytes, _ := json.Marshal(disabledCache)
+	stub.On("GetState", "disabledkey").Return(disabledCacheBytes, nil)
+
+	//a visit on a disabled cache is rejected
+	err := c.AddVisitorToGeoCache(ctx, *u, "disabledkey", 6, 6, "")
+	assert.EqualError(t, err, "The cache disabledkey is not active!", "should error when the cache is not active")
+}
+
+func TestCreateGeoCacheGeo(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetStateByRange", "", "").Return(configureIterator(), nil)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a position outside the globe is rejected before the world state is touched
+	_, err = c.CreateGeoCacheGeo(ctx, *u, "missingkey", "some value", "testDescription", 91, 19.0402, 100, "asd", "", "", "")
+	assert.EqualError(t, err, "The latitude must be between -90 and 90!", "should error for an invalid latitude")
+
+	_, err = c.CreateGeoCacheGeo(ctx, *u, "missingkey", "some value", "testDescription", 47.4979, 181, 100, "asd", "", "", "")
+	assert.EqualError(t, err, "The longitude must be between -180 and 180!", "should error for an invalid longitude")
+
+	_, err = c.CreateGeoCacheGeo(ctx, *u, "missingkey", "some value", "testDescription", 47.4979, 19.0402, 0, "asd", "", "", "")
+	assert.EqualError(t, err, "The radius must be positive!", "should error for a non positive radius")
+
+	//a valid GPS cache is stored with its position and radius
+	trackable, err := c.CreateGeoCacheGeo(ctx, *u, "missingkey", "some value", "testDescription", 47.4979, 19.0402, 100, "asd", "", "", "")
+	assert.Nil(t, err, "should not return error for a valid GPS cache")
+	assert.NotNil(t, trackable, "should return the generated trackable")
+
+	stub.AssertCalled(t, "PutState", "missingkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Latitude == 47.4979 && storedCache.Longitude == 19.0402 &&
+			storedCache.RadiusMeters == 100 && storedCache.Status == statusActive
+	}))
+}
+
+func TestAddVisitorGeo(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a GPS cache with a 500 meter find radius
+	gpsCache := new(GeoCache)
+	gpsCache.Name = "gps cache"
+	gpsCache.Latitude = 47.4979
+	gpsCache.Longitude = 19.0402
+	gpsCache.RadiusMeters = 500
+	gpsCacheBytes, _ := json.Marshal(gpsCache)
+	stub.On("GetState", "geokey").Return(gpsCacheBytes, nil)
+
+	//the legacy fixture cache has no find radius, so the GPS check in is rejected
+	err = c.AddVisitorGeo(ctx, *u, "geoCachekey", 47.4979, 19.0402, "")
+	assert.EqualError(t, err, "The cache geoCachekey has no find radius!", "should error for a cache without a radius")
+
+	//a point roughly one kilometer to the north is outside the radius
+	err = c.AddVisitorGeo(ctx, *u, "geokey", 47.5069, 19.0402, "")
+	assert.EqualError(t, err, "You are not within the find radius of the cache!", "should error outside the radius")
+
+	//a point a few meters away is within the radius
+	err = c.AddVisitorGeo(ctx, *u, "geokey", 47.4980, 19.0403, "")
+	assert.Nil(t, err, "should not return error within the radius")
+
+	stub.AssertCalled(t, "PutState", "geokey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.Visitors) == 1 && storedCache.Visitors[0].Name == "TestUser" &&
+			len(storedCache.VisitLog) == 1
+	}))
+
+	//a GPS cache the user checked in on at the mocked transaction time
+	visitedGpsCache := new(GeoCache)
+	visitedGpsCache.Name = "visited gps cache"
+	visitedGpsCache.Latitude = 47.4979
+	visitedGpsCache.Longitude = 19.0402
+	visitedGpsCache.RadiusMeters = 100
+	visitedGpsCache.Visitors = []User{*u}
+	visitedGpsCache.VisitLog = []Visit{{User: *u, Timestamp: "1970-01-12T13:46:40Z"}}
+	visitedGpsCacheBytes, _ := json.Marshal(visitedGpsCache)
+	stub.On("GetState", "geovisitedkey").Return(visitedGpsCacheBytes, nil)
+
+	//a GPS re-check in is held back by the same cooldown as a range one instead of a permanent rejection
+	err = c.AddVisitorGeo(ctx, *u, "geovisitedkey", 47.4980, 19.0403, "")
+	assert.EqualError(t, err, "The user TestUser has to wait before checking in again!", "should error within the cooldown")
+
+	//a GPS cache whose last check-in of the user is a full cooldown in the past
+	cooledGpsCache := new(GeoCache)
+	cooledGpsCache.Name = "cooled gps cache"
+	cooledGpsCache.Latitude = 47.4979
+	cooledGpsCache.Longitude = 19.0402
+	cooledGpsCache.RadiusMeters = 100
+	cooledGpsCache.Visitors = []User{*u}
+	cooledGpsCache.VisitLog = []Visit{{User: *u, Timestamp: formatTimestamp(1000000 - visitCooldownSeconds)}}
+	cooledGpsCacheBytes, _ := json.Marshal(cooledGpsCache)
+	stub.On("GetState", "geocooledkey").Return(cooledGpsCacheBytes, nil)
+
+	//once the cooldown has passed the re-check in extends the visit log without duplicating the visitor
+	err = c.AddVisitorGeo(ctx, *u, "geocooledkey", 47.4980, 19.0403, "")
+	assert.Nil(t, err, "should not return error after the cooldown")
+
+	stub.AssertCalled(t, "PutState", "geocooledkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.Visitors) == 1 && len(storedCache.VisitLog) == 2
+	}))
+}
+
+func TestHashCredential(t *testing.T) {
+	//a fixed input must always produce the same PBKDF2 digest
+	expected := "44bd3fba16a7131482db692ed4a21923f206836da7ad1cdde4be00213ff3b202"
+	assert.Equal(t, expected, hashCredential("123", "123"), "should derive the known digest from the fixed input")
+
+	//both the new digest and the legacy stretched SHA1 authenticate
+	assert.True(t, verifyCredential(expected, "123", "123"), "should accept the PBKDF2 digest")
+	assert.True(t, verifyCredential("4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", "123", "123"), "should accept the legacy SHA1 digest")
+	assert.False(t, verifyCredential(expected, "456", "123"), "should reject a wrong id")
+}
+
+func TestIsInRange(t *testing.T) {
+	//the boundaries of the range count as inside
+	tests := []struct {
+		name     string
+		coord    int
+		r        [2]int
+		expected bool
+	}{
+		{"lower bound", 5, [2]int{5, 10}, true},
+		{"upper bound", 10, [2]int{5, 10}, true},
+		{"inside", 7, [2]int{5, 10}, true},
+		{"below", 4, [2]int{5, 10}, false},
+		{"above", 11, [2]int{5, 10}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isInRange(tt.coord, tt.r), tt.name)
+	}
+}
+
+func TestAddVisitorToGeoCacheBoundary(t *testing.T) {
+	var err error
+
+	ctx, _ := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a visitor standing exactly on the boundary coordinate is let in
+	err = c.AddVisitorToGeoCache(ctx, *u, "geoCachekey", 5, 10, "")
+	assert.Nil(t, err, "should not return error when the visitor stands on the boundary")
+
+	//one step outside is still rejected
+	err = c.AddVisitorToGeoCache(ctx, *u, "geoCachekey", 4, 10, "")
+	assert.EqualError(t, err, "You are not in the cache's location range!", "should error when the visitor is outside the range")
+}
+
+func TestGetAvailableFunctions(t *testing.T) {
+	ctx, _ := configureStub()
+	c := new(GeoCacheContract)
+
+	functions, err := c.GetAvailableFunctions(ctx)
+	assert.Nil(t, err, "should not return error when listing the functions")
+	assert.Contains(t, functions, "CreateGeoCache", "should contain the create transaction")
+	assert.Contains(t, functions, "AddVisitorToGeoCache", "should contain the visit transaction")
+	assert.Contains(t, functions, "GetAvailableFunctions", "should contain itself")
+}
+
+func TestGetOwnedUnvisitedByOwner(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	//the stored owner id is myHash("123" + "123")
+	hashedOwner := User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+
+	//one owned cache the owner already found
+	foundCache := new(GeoCache)
+	foundCache.Name = "found"
+	foundCache.Owner = hashedOwner
+	foundCache.VisitLog = []Visit{{User: *owner, Xcoord: 6, Ycoord: 6, Timestamp: "1970-01-12T13:46:40Z"}}
+	foundCacheBytes, _ := json.Marshal(foundCache)
+
+	//one owned cache without a find of the owner
+	unfoundCache := new(GeoCache)
+	unfoundCache.Name = "unfound"
+	unfoundCache.Owner = hashedOwner
+	unfoundCacheBytes, _ := json.Marshal(unfoundCache)
+
+	//someone else's cache is not part of the view at all
+	foreignCache := new(GeoCache)
+	foreignCache.Name = "foreign"
+	foreignCache.Owner = User{Id: "otherhash", Name: "Other", Salt: "xyz"}
+	foreignCacheBytes, _ := json.Marshal(foreignCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: foundCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: unfoundCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: foreignCacheBytes},
+	), nil)
+
+	caches, err := c.GetOwnedUnvisitedByOwner(ctx, *owner)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, caches, 1, "should only return the owned cache without a find")
+	assert.Equal(t, "unfound", caches[0].Name)
+}
+
+func TestGetUserHeldTrackable(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	holder := new(User)
+	holder.Id = "123"
+	holder.Name = "TestUser"
+
+	emptyHanded := new(User)
+	emptyHanded.Id = "456"
+	emptyHanded.Name = "EmptyHanded"
+
+	//the holder picked up a trackable at an earlier switch
+	heldTrackable := new(Trackable)
+	heldTrackable.Id = "testId"
+	heldTrackable.Value = "testValue"
+	heldBytes, _ := json.Marshal(heldTrackable)
+	stub.On("GetState", "held_123").Return(heldBytes, nil)
+	stub.On("GetState", "held_456").Return([]byte(nil), nil)
+
+	trackable, err := c.GetUserHeldTrackable(ctx, *holder)
+	assert.Nil(t, err, "should not return error when the user holds a trackable")
+	assert.Equal(t, heldTrackable, trackable, "should return the held trackable")
+
+	//a user without a held record gets a not found error
+	_, err = c.GetUserHeldTrackable(ctx, *emptyHanded)
+	assert.EqualError(t, err, "The user EmptyHanded does not hold a trackable", "should error when the user holds nothing")
+}
+
+func TestGetRepeatVisitorCount(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	other := new(User)
+	other.Id = "789"
+	other.Name = "Other"
+
+	//one visitor came back, the other only visited once
+	repeatVisitor := User{Id: "456", Name: "Repeat"}
+	singleVisitor := User{Id: "567", Name: "Single"}
+
+	trackedCache := new(GeoCache)
+	trackedCache.Name = "tracked cache"
+	trackedCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	trackedCache.VisitLog = []Visit{
+		{User: repeatVisitor, Timestamp: "2021-05-01T10:00:00Z"},
+		{User: singleVisitor, Timestamp: "2021-05-02T10:00:00Z"},
+		{User: repeatVisitor, Timestamp: "2021-05-03T10:00:00Z"},
+	}
+	trackedCacheBytes, _ := json.Marshal(trackedCache)
+	stub.On("GetState", "trackedkey").Return(trackedCacheBytes, nil)
+
+	//only the owner can read the metrics
+	_, err := c.GetRepeatVisitorCount(ctx, *other, "trackedkey")
+	assert.EqualError(t, err, "Only the owner can view the visitor metrics!", "should error when not the owner asks")
+
+	count, err := c.GetRepeatVisitorCount(ctx, *owner, "trackedkey")
+	assert.Nil(t, err, "should not return error when the owner asks")
+	assert.Equal(t, 1, count, "should only count the visitor with more than one visit")
+}
+
+func TestHasUserVisited(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	visitor := new(User)
+	visitor.Id = "456"
+	visitor.Name = "Visitor"
+
+	//a cache the visitor already found
+	visitedCache := new(GeoCache)
+	visitedCache.Name = "visited cache"
+	visitedCache.Visitors = []User{*visitor}
+	visitedCacheBytes, _ := json.Marshal(visitedCache)
+	stub.On("GetState", "visitedkey").Return(visitedCacheBytes, nil)
+
+	visited, err := c.HasUserVisited(ctx, "visitedkey", *visitor)
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.True(t, visited, "should report the present visitor as visited")
+
+	//a different name with the same id is still the same user
+	renamed := User{Id: "456", Name: "Renamed"}
+	visited, err = c.HasUserVisited(ctx, "visitedkey", renamed)
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.True(t, visited, "should match visitors on the id only")
+
+	//an absent user did not visit
+	other := User{Id: "789", Name: "Other"}
+	visited, err = c.HasUserVisited(ctx, "visitedkey", other)
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.False(t, visited, "should report the absent visitor as not visited")
+
+	//the reader errors are passed through
+	_, err = c.HasUserVisited(ctx, "missingkey", *visitor)
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when the cache does not exist")
+}
+
+func TestGetVisitorCount(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//the fixture cache has no visitors yet
+	count, err := c.GetVisitorCount(ctx, "geoCachekey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Equal(t, 0, count, "should return zero for a cache without visitors")
+
+	//a cache with three visitors
+	visitedCache := new(GeoCache)
+	visitedCache.Name = "visited cache"
+	visitedCache.Visitors = []User{{Id: "1"}, {Id: "2"}, {Id: "3"}}
+	visitedCacheBytes, _ := json.Marshal(visitedCache)
+	stub.On("GetState", "visitedkey").Return(visitedCacheBytes, nil)
+
+	count, err = c.GetVisitorCount(ctx, "visitedkey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Equal(t, 3, count, "should return the number of visitors")
+
+	//the reader errors are passed through
+	_, err = c.GetVisitorCount(ctx, "missingkey")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when the cache does not exist")
+}
+
+func TestDedupVisitors(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	visitor := new(User)
+	visitor.Id = "456"
+	visitor.Name = "Visitor"
+
+	other := new(User)
+	other.Id = "789"
+	other.Name = "Other"
+
+	//a cache holding the same visitor three times, the stored owner id is myHash("123" + "123")
+	dupCache := new(GeoCache)
+	dupCache.Name = "dup cache"
+	dupCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	dupCache.Visitors = []User{*visitor, *other, *visitor, *visitor}
+	dupCacheBytes, _ := json.Marshal(dupCache)
+	stub.On("GetState", "dupkey").Return(dupCacheBytes, nil)
+
+	//only the owner can run the cleanup
+	removed, err := c.DedupVisitors(ctx, *other, "dupkey")
+	assert.EqualError(t, err, "Only the owner can update a cache!", "should error when not the owner runs the dedup")
+
+	//the two duplicate entries are removed, the first occurrence stays
+	removed, err = c.DedupVisitors(ctx, *owner, "dupkey")
+	assert.Nil(t, err, "should not return error when the owner runs the dedup")
+	assert.Equal(t, 2, removed, "should remove the two duplicate visitor entries")
+}
+
+func TestReadGeoCachePublic(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//an unpublished cache with exact ranges inside one grid cell
+	draftCache := new(GeoCache)
+	draftCache.Name = "draft cache"
+	draftCache.Owner = User{Id: "hashed", Name: "TestUser", Salt: "secret"}
+	draftCache.XcoordRange = [2]int{60, 70}
+	draftCache.YcoordRange = [2]int{110, 120}
+	draftCacheBytes, _ := json.Marshal(draftCache)
+	stub.On("GetState", "draftkey").Return(draftCacheBytes, nil)
+
+	//the public read snaps the ranges outwards to the coarse grid and hides the salt
+	publicCache, err := c.ReadGeoCachePublic(ctx, "draftkey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Equal(t, [2]int{50, 100}, publicCache.XcoordRange, "should snap the X range to the grid")
+	assert.Equal(t, [2]int{100, 150}, publicCache.YcoordRange, "should snap the Y range to the grid")
+	assert.Equal(t, "", publicCache.Owner.Salt, "should strip the owner salt from the public read")
+
+	//a published cache keeps its exact ranges
+	publishedCache := new(GeoCache)
+	publishedCache.Name = "published cache"
+	publishedCache.XcoordRange = [2]int{60, 70}
+	publishedCache.YcoordRange = [2]int{110, 120}
+	publishedCache.Published = true
+	publishedCacheBytes, _ := json.Marshal(publishedCache)
+	stub.On("GetState", "publishedkey").Return(publishedCacheBytes, nil)
+
+	publicCache, err = c.ReadGeoCachePublic(ctx, "publishedkey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Equal(t, [2]int{60, 70}, publicCache.XcoordRange, "should keep the exact X range of a published cache")
+	assert.Equal(t, [2]int{110, 120}, publicCache.YcoordRange, "should keep the exact Y range of a published cache")
+}
+
+func TestPublishGeoCache(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	other := new(User)
+	other.Id = "789"
+	other.Name = "Other"
+
+	//a draft cache of the owner, the stored id is myHash("123" + "123")
+	draftCache := new(GeoCache)
+	draftCache.Name = "draft cache"
+	draftCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	draftCacheBytes, _ := json.Marshal(draftCache)
+	stub.On("GetState", "draftkey").Return(draftCacheBytes, nil)
+
+	//the draft is hidden from the public listing
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "draftkey", Value: draftCacheBytes},
+	), nil).Once()
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "draftkey", Value: draftCacheBytes},
+	), nil).Once()
+
+	caches, err := c.GetAllGeoCaches(ctx)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, caches, 0, "should hide the unpublished cache from the public listing")
+
+	//the owner still sees the draft through the owner view
+	ownedCaches, err := c.GetOwnedUnvisitedByOwner(ctx, *owner)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, ownedCaches, 1, "should show the draft to the owner")
+
+	//only the owner can publish
+	err = c.PublishGeoCache(ctx, *other, "draftkey")
+	assert.EqualError(t, err, "Only the owner can update a cache!", "should error when not the owner publishes")
+
+	//publishing stores the cache with the flag set
+	err = c.PublishGeoCache(ctx, *owner, "draftkey")
+	assert.Nil(t, err, "should not return error when the owner publishes")
+	stub.AssertCalled(t, "PutState", "draftkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Published
+	}))
+
+	//unpublishing clears the flag again
+	err = c.UnpublishGeoCache(ctx, *owner, "draftkey")
+	assert.Nil(t, err, "should not return error when the owner unpublishes")
+	stub.AssertCalled(t, "PutState", "draftkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return !storedCache.Published
+	}))
+}
+
+func TestGetAllGeoCaches(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	firstCache := new(GeoCache)
+	firstCache.Name = "first cache"
+	firstCache.Owner = User{Id: "hashed", Name: "TestUser", Salt: "secret"}
+	firstCache.Published = true
+	firstCacheBytes, _ := json.Marshal(firstCache)
+
+	secondCache := new(GeoCache)
+	secondCache.Name = "second cache"
+	secondCache.Owner = User{Id: "otherhash", Name: "Other", Salt: "alsosecret"}
+	secondCache.Published = true
+	secondCacheBytes, _ := json.Marshal(secondCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: firstCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: []byte("not a cache")},
+		&queryresult.KV{Key: "cache3", Value: secondCacheBytes},
+	), nil)
+
+	//the invalid value is skipped and the salts are stripped
+	caches, err := c.GetAllGeoCaches(ctx)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, caches, 2, "should skip the value that is not a cache")
+	assert.Equal(t, "first cache", caches[0].Name)
+	assert.Equal(t, "second cache", caches[1].Name)
+	assert.Equal(t, "", caches[0].Owner.Salt, "should strip the owner salt from the listing")
+	assert.Equal(t, "", caches[1].Owner.Salt, "should strip the owner salt from the listing")
+}
+
+func TestGetGeoCachesWithPagination(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	firstCache := new(GeoCache)
+	firstCache.Name = "first cache"
+	firstCache.Owner = User{Id: "hashed", Name: "TestUser", Salt: "storedsalt"}
+	firstCache.Published = true
+	firstCacheBytes, _ := json.Marshal(firstCache)
+
+	secondCache := new(GeoCache)
+	secondCache.Name = "second cache"
+	secondCache.Published = true
+	secondCacheBytes, _ := json.Marshal(secondCache)
+
+	thirdCache := new(GeoCache)
+	thirdCache.Name = "third cache"
+	thirdCache.Published = true
+	thirdCacheBytes, _ := json.Marshal(thirdCache)
+
+	//the first page ends with a bookmark pointing at the second one
+	stub.On("GetStateByRangeWithPagination", "", "", int32(2), "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: firstCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: secondCacheBytes},
+	), &peer.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "cache3"}, nil)
+	stub.On("GetStateByRangeWithPagination", "", "", int32(2), "cache3").Return(configureIterator(
+		&queryresult.KV{Key: "cache3", Value: thirdCacheBytes},
+	), &peer.QueryResponseMetadata{FetchedRecordsCount: 1, Bookmark: ""}, nil)
+
+	//a non positive page size is rejected
+	_, err := c.GetGeoCachesWithPagination(ctx, 0, "")
+	assert.EqualError(t, err, "The page size must be positive!", "should error when the page size is not positive")
+
+	firstPage, err := c.GetGeoCachesWithPagination(ctx, 2, "")
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, firstPage.Caches, 2, "should return the first page of caches")
+	assert.Equal(t, int32(2), firstPage.FetchedRecordsCount)
+	assert.Equal(t, "cache3", firstPage.Bookmark, "should return the bookmark of the next page")
+	assert.Equal(t, "", firstPage.Caches[0].Owner.Salt, "should not leak the stored salt")
+
+	//the bookmark of the first page fetches the second page
+	secondPage, err := c.GetGeoCachesWithPagination(ctx, 2, firstPage.Bookmark)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, secondPage.Caches, 1, "should return the last page of caches")
+	assert.Equal(t, "third cache", secondPage.Caches[0].Name)
+	assert.Equal(t, "", secondPage.Bookmark, "should return an empty bookmark on the last page")
+
+	//a raw page full of drafts must not come back as an empty page while more data exists
+	draftCache := new(GeoCache)
+	draftCache.Name = "draft cache"
+	draftCacheBytes, _ := json.Marshal(draftCache)
+
+	draftCtx, draftStub := configureStub()
+	draftStub.On("GetStateByRangeWithPagination", "", "", int32(2), "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: firstCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: draftCacheBytes},
+	), &peer.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "cache3"}, nil)
+	draftStub.On("GetStateByRangeWithPagination", "", "", int32(1), "cache3").Return(configureIterator(
+		&queryresult.KV{Key: "cache3", Value: thirdCacheBytes},
+	), &peer.QueryResponseMetadata{FetchedRecordsCount: 1, Bookmark: ""}, nil)
+
+	filledPage, err := c.GetGeoCachesWithPagination(draftCtx, 2, "")
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, filledPage.Caches, 2, "should fill the page from the next raw page when drafts are skipped")
+	assert.Equal(t, "first cache", filledPage.Caches[0].Name)
+	assert.Equal(t, "third cache", filledPage.Caches[1].Name)
+	assert.Equal(t, int32(2), filledPage.FetchedRecordsCount, "should count the returned caches, not the raw records")
+	assert.Equal(t, "", filledPage.Bookmark, "should return an empty bookmark once the range is exhausted")
+}
+
+func TestGetTagCounts(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//three caches with overlapping tags
+	forestCache := new(GeoCache)
+	forestCache.Name = "forest"
+	forestCache.Tags = []string{"forest", "hiking"}
+	forestCache.Published = true
+	forestCacheBytes, _ := json.Marshal(forestCache)
+
+	hillCache := new(GeoCache)
+	hillCache.Name = "hill"
+	hillCache.Tags = []string{"hiking"}
+	hillCache.Published = true
+	hillCacheBytes, _ := json.Marshal(hillCache)
+
+	urbanCache := new(GeoCache)
+	urbanCache.Name = "urban"
+	urbanCache.Published = true
+	urbanCacheBytes, _ := json.Marshal(urbanCache)
+
+	//the tags of a draft must not leak into the public statistics
+	draftCache := new(GeoCache)
+	draftCache.Name = "draft"
+	draftCache.Tags = []string{"forest"}
+	draftCacheBytes, _ := json.Marshal(draftCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: forestCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: hillCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: urbanCacheBytes},
+		&queryresult.KV{Key: "cache4", Value: draftCacheBytes},
+	), nil)
+
+	counts, err := c.GetTagCounts(ctx)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, counts, 2, "should only count the tags that appear on caches")
+	assert.Equal(t, 1, counts["forest"], "should count the forest tag once")
+	assert.Equal(t, 2, counts["hiking"], "should count the hiking tag on both caches")
+}
+
+func TestGetCachesWithMissionTrackables(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//one trackable on a mission through the region and one going elsewhere
+	matching := new(GeoCache)
+	matching.Name = "matching"
+	matching.Trackable = Trackable{Id: "t1", Value: "coin", Mission: "travel across Europe"}
+	matching.Published = true
+	matchingBytes, _ := json.Marshal(matching)
+
+	nonMatching := new(GeoCache)
+	nonMatching.Name = "non matching"
+	nonMatching.Trackable = Trackable{Id: "t2", Value: "tag", Mission: "reach the coast of Asia"}
+	nonMatching.Published = true
+	nonMatchingBytes, _ := json.Marshal(nonMatching)
+
+	//a draft with a matching mission must stay hidden from the search
+	draft := new(GeoCache)
+	draft.Name = "draft"
+	draft.Trackable = Trackable{Id: "t3", Value: "coin", Mission: "travel across Europe"}
+	draftBytes, _ := json.Marshal(draft)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: matchingBytes},
+		&queryresult.KV{Key: "cache2", Value: nonMatchingBytes},
+		&queryresult.KV{Key: "cache3", Value: draftBytes},
+	), nil)
+
+	//an empty region is rejected before touching the world state
+	_, err := c.GetCachesWithMissionTrackables(ctx, "")
+	assert.EqualError(t, err, "The region must not be empty!", "should error when the region is empty")
+
+	//only the cache with the matching mission should be returned
+	caches, err := c.GetCachesWithMissionTrackables(ctx, "Europe")
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, caches, 1, "should only return the cache with the matching mission")
+	assert.Equal(t, "matching", caches[0].Name)
+}
+
+func TestSwitchTrackable(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	trackable := new(Trackable)
+	trackable.Id = "testId"
+	trackable.Value = "testValue"
+
+	// statebad returns nilBytes and an error, so the function should return with error
+	_, err = c.SwitchTrackable(ctx, *u, *trackable, "statebad")
+	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+
+	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
+	_, err = c.SwitchTrackable(ctx, *u, *trackable, "missingkey")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
+
+	//the fixture cache has no logged visit of the user, so the swap is rejected
+	_, err = c.SwitchTrackable(ctx, *u, *trackable, "geoCachekey")
+	assert.EqualError(t, err, "Only a visitor of the cache can switch its trackable!", "should error for a caller without a logged visit")
+
+	//a cache the user has logged a visit on allows the swap
+	visitedCache := new(GeoCache)
+	visitedCache.Name = "visited cache"
+	visitedCache.Trackable = Trackable{Id: "testId", Value: "testValue"}
+	visitedCache.VisitLog = []Visit{{User: *u, Timestamp: "1970-01-12T13:46:40Z"}}
+	visitedCacheBytes, _ := json.Marshal(visitedCache)
+	stub.On("GetState", "switchkey").Return(visitedCacheBytes, nil)
+
+	switchedTrackable, err := c.SwitchTrackable(ctx, *u, *trackable, "switchkey")
+	assert.Nil(t, err, "should not return error for a logged visitor")
+
+	expectedTrackable := new(Trackable)
+	expectedTrackable.Id = "testId"
+	expectedTrackable.Value = "testValue"
+
+	assert.Equal(t, switchedTrackable, expectedTrackable)
+}
+
+func TestUpdateTrackableValue(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
+	err = c.UpdateTrackableValue(ctx, *u, "missingkey", "new note")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error for a missing cache")
+
+	//the fixture cache has no logged visit of the user, so the edit is rejected
+	err = c.UpdateTrackableValue(ctx, *u, "geoCachekey", "new note")
+	assert.EqualError(t, err, "Only a visitor of the cache can update its trackable!", "should error for a caller without a logged visit")
+
+	//a cache the user has logged a visit on allows the edit
+	visitedCache := new(GeoCache)
+	visitedCache.Name = "visited cache"
+	visitedCache.Trackable = Trackable{Id: "testId", Value: "testValue"}
+	visitedCache.VisitLog = []Visit{{User: *u, Timestamp: "1970-01-12T13:46:40Z"}}
+	visitedCacheBytes, _ := json.Marshal(visitedCache)
+	stub.On("GetState", "editkey").Return(visitedCacheBytes, nil)
+
+	err = c.UpdateTrackableValue(ctx, *u, "editkey", "new note")
+	assert.Nil(t, err, "should not return error for a logged visitor")
+
+	//the new value is persisted on the trackable of the cache
+	stub.AssertCalled(t, "PutState", "editkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Trackable.Id == "testId" && storedCache.Trackable.Value == "new note"
+	}))
+}
+
+func TestSwitchTrackableRecordsUser(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	trackable := new(Trackable)
+	trackable.Id = "newId"
+	trackable.Value = "newValue"
+
+	//a cache with a logged visit of the user, so the swap is authorized
+	recordedCache := new(GeoCache)
+	recordedCache.Id = "recordedkey"
+	recordedCache.Name = "recorded cache"
+	recordedCache.Trackable = Trackable{Id: "oldId", Value: "oldValue"}
+	recordedCache.VisitLog = []Visit{{User: *u, Timestamp: "1970-01-12T13:46:40Z"}}
+	recordedCacheBytes, _ := json.Marshal(recordedCache)
+	stub.On("GetState", "recordedkey").Return(recordedCacheBytes, nil)
+
+	//the switch should succeed and store the incoming trackable with an attributed move entry
+	_, err := c.SwitchTrackable(ctx, *u, *trackable, "recordedkey")
+	assert.Nil(t, err, "should not return error when GeoCache exists in world state")
+
+	expectedGeoCache := new(GeoCache)
+	expectedGeoCache.Id = "recordedkey"
+	expectedGeoCache.Name = "recorded cache"
+	expectedGeoCache.VisitLog = []Visit{{User: *u, Timestamp: "1970-01-12T13:46:40Z"}}
+
+	//the stored trackable is the new one, with the move attributed to the dropping user
+	expectedGeoCache.Trackable = *trackable
+	expectedGeoCache.Trackable.History = append(expectedGeoCache.Trackable.History, TrackableMove{ToCacheId: "recordedkey", Timestamp: "1970-01-12T13:46:40Z", MovedBy: *u})
+
+	//the modification is recorded in the access log
+	expectedGeoCache.AccessLog = append(expectedGeoCache.AccessLog, AccessEntry{ClientId: "testClient", Operation: "SwitchTrackable", Timestamp: "1970-01-12T13:46:40Z"})
+	expectedGeoCache.UpdatedAt = "1970-01-12T13:46:40Z"
+	expectedGeoCache.Version = 1
+	expectedGeoCacheBytes, _ := json.Marshal(expectedGeoCache)
+
+	//put state should called with the expected cache value
+	stub.AssertCalled(t, "PutState", "recordedkey", expectedGeoCacheBytes)
+}
+
+func TestSwitchTrackableHistory(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a trackable that was last dropped into another cache
+	trackable := new(Trackable)
+	trackable.Id = "travelledId"
+	trackable.Value = "travelledValue"
+	trackable.History = []TrackableMove{{ToCacheId: "otherCache", Timestamp: "1970-01-10T00:00:00Z", MovedBy: *u}}
+
+	//the previous cache is not in the world state any more, the move is still recorded
+	var nilBytes []byte
+	stub.On("GetState", "otherCache").Return(nilBytes, nil)
+
+	//a cache with a logged visit of the user, so the swap is authorized
+	travelCache := new(GeoCache)
+	travelCache.Name = "travel cache"
+	travelCache.VisitLog = []Visit{{User: *u, Timestamp: "1970-01-12T13:46:40Z"}}
+	travelCacheBytes, _ := json.Marshal(travelCache)
+	stub.On("GetState", "travelkey").Return(travelCacheBytes, nil)
+
+	_, err := c.SwitchTrackable(ctx, *u, *trackable, "travelkey")
+	assert.Nil(t, err, "should not return error when GeoCache exists in world state")
+
+	//the stored trackable gained exactly one move, from the previous cache to this one
+	stub.AssertCalled(t, "PutState", "travelkey", mock.MatchedBy(func(data []byte) bool {
+		geoCache := new(GeoCache)
+		if err := json.Unmarshal(data, geoCache); err != nil {
+			return false
+		}
+		if len(geoCache.Trackable.History) != 2 {
+			return false
+		}
+		move := geoCache.Trackable.History[1]
+		return move.FromCacheId == "otherCache" && move.ToCacheId == "travelkey" && move.Timestamp == "1970-01-12T13:46:40Z"
+	}))
+}
+
+func TestSwitchTrackableDistance(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//the first previous cache sits 5 units west of geoCachekey's center
+	cacheA := new(GeoCache)
+	cacheA.XcoordRange = [2]int{0, 5}
+	cacheA.YcoordRange = [2]int{5, 10}
+	cacheABytes, _ := json.Marshal(cacheA)
+	stub.On("GetState", "cacheA").Return(cacheABytes, nil)
+
+	//the second previous cache sits 5 units south of geoCachekey's center
+	cacheB := new(GeoCache)
+	cacheB.XcoordRange = [2]int{5, 10}
+	cacheB.YcoordRange = [2]int{0, 5}
+	cacheBBytes, _ := json.Marshal(cacheB)
+	stub.On("GetState", "cacheB").Return(cacheBBytes, nil)
+
+	trackable := new(Trackable)
+	trackable.Id = "travellerId"
+	trackable.Value = "travellerValue"
+	trackable.History = []TrackableMove{{ToCacheId: "cacheA", Timestamp: "1970-01-10T00:00:00Z", MovedBy: *u}}
+
+	//the target cache with a logged visit of the user, so the swap is authorized
+	targetCache := new(GeoCache)
+	targetCache.Name = "target cache"
+	targetCache.XcoordRange = [2]int{5, 10}
+	targetCache.YcoordRange = [2]int{5, 10}
+	targetCache.VisitLog = []Visit{{User: *u, Timestamp: "1970-01-12T13:46:40Z"}}
+	targetCacheBytes, _ := json.Marshal(targetCache)
+	stub.On("GetState", "targetkey").Return(targetCacheBytes, nil)
+
+	//the first switch covers the 5 units from cacheA
+	_, err := c.SwitchTrackable(ctx, *u, *trackable, "targetkey")
+	assert.Nil(t, err, "should not return error on the first switch")
+	stub.AssertCalled(t, "PutState", "targetkey", mock.MatchedBy(func(data []byte) bool {
+		geoCache := new(GeoCache)
+		if err := json.Unmarshal(data, geoCache); err != nil {
+			return false
+		}
+		return geoCache.Trackable.DistanceTraveled == 5
+	}))
+
+	//the second switch starts from the accumulated distance of the first
+	trackable.DistanceTraveled = 5
+	trackable.History = []TrackableMove{{ToCacheId: "cacheB", Timestamp: "1970-01-11T00:00:00Z", MovedBy: *u}}
+
+	_, err = c.SwitchTrackable(ctx, *u, *trackable, "targetkey")
+	assert.Nil(t, err, "should not return error on the second switch")
+	stub.AssertCalled(t, "PutState", "targetkey", mock.MatchedBy(func(data []byte) bool {
+		geoCache := new(GeoCache)
+		if err := json.Unmarshal(data, geoCache); err != nil {
+			return false
+		}
+		return geoCache.Trackable.DistanceTraveled == 10
+	}))
+}
+
+func TestUpdateCoordGeoCache(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+	u.Salt = "123"
+
+	// statebad returns nilBytes and an error, so the function should return with error
+	err = c.UpdateCoordGeoCache(ctx, *u, "statebad", [2]int{7, 10}, [2]int{7, 10})
+	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+
+	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
+	err = c.UpdateCoordGeoCache(ctx, *u, "missingkey", [2]int{7, 10}, [2]int{7, 10})
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
+
+	// geoCachekey returns with a valid value and no error, so the function shouldnt return woth an error
+	err = c.UpdateCoordGeoCache(ctx, *u, "geoCachekey", [2]int{7, 10}, [2]int{7, 10})
+	assert.Nil(t, err, "should not return error when GeoCache exists in world state when deleting")
+
+	expectedGeoCache := new(GeoCache)
+	expectedGeoCache.Id = "geoCachekey"
+	expectedGeoCache.Name = "set value"
+
+	// expected user in the expected cache
+	u2 := new(User)
+	u2.Id = "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba"
+	u2.Name = "TestUser"
+	u2.Salt = "123"
 
 	trackable := new(Trackable)
 	expectedGeoCache.Trackable = *trackable
 	expectedGeoCache.Trackable.Id = "testId"
 	expectedGeoCache.Trackable.Value = "testValue"
 
-	report := new(Report)
-	report.Id = "testId"
-	report.Message = "TestMessage"
-	report.Notifier = *u2
-	expectedGeoCache.Reports = append(expectedGeoCache.Reports, *report)
+	report := new(Report)
+	report.Id = "testId"
+	report.Message = "TestMessage"
+	report.Notifier = *u2
+	expectedGeoCache.Reports = append(expectedGeoCache.Reports, *report)
+
+	expectedGeoCache.Owner = *u2
+	//adding the new visitor
+	expectedGeoCache.XcoordRange = [2]int{7, 10}
+	expectedGeoCache.YcoordRange = [2]int{7, 10}
+	//the scalar bound fields follow the ranges
+	expectedGeoCache.MinX = 7
+	expectedGeoCache.MaxX = 10
+	expectedGeoCache.MinY = 7
+	expectedGeoCache.MaxY = 10
+	//the modification is recorded in the access log
+	expectedGeoCache.AccessLog = append(expectedGeoCache.AccessLog, AccessEntry{ClientId: "testClient", Operation: "UpdateCoordGeoCache", Timestamp: "1970-01-12T13:46:40Z"})
+	expectedGeoCache.UpdatedAt = "1970-01-12T13:46:40Z"
+	expectedGeoCache.Version = 1
+	expectedGeoCacheBytes, _ := json.Marshal(expectedGeoCache)
+
+	//put state should called with the expected cache value
+	stub.AssertCalled(t, "PutState", "geoCachekey", expectedGeoCacheBytes)
+}
+
+func TestReportGeoCache(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetState", reportsEnabledKey).Return([]byte(nil), nil)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+	u.Salt = "123"
+
+	//an empty message is rejected before anything else
+	err = c.ReportGeoCache(ctx, *u, "", "geoCachekey", "", "")
+	assert.EqualError(t, err, "The report message must not be empty!", "should error for an empty report message")
+
+	// statebad returns nilBytes and an error, so the function should return with error
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "statebad", "", "")
+	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+
+	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "missingkey", "", "")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
+
+	// geoCachekey returns with a valid value and no error, so the function shouldnt return with an error
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "geoCachekey", "", "")
+	assert.Nil(t, err, "should not return error when GeoCache exists in world state when deleting")
+}
+
+func TestReportGeoCacheNeedsMaintenance(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetState", reportsEnabledKey).Return([]byte(nil), nil)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+	u.Salt = "123"
+
+	//a cache that two distinct users already reported
+	flaggedCache := new(GeoCache)
+	flaggedCache.Name = "flagged cache"
+	flaggedCache.Reports = []Report{
+		{Id: "r1", Message: "broken", Notifier: User{Id: "firstReporter"}},
+		{Id: "r2", Message: "missing", Notifier: User{Id: "secondReporter"}},
+	}
+	flaggedBytes, _ := json.Marshal(flaggedCache)
+	stub.On("GetState", "flaggedkey").Return(flaggedBytes, nil)
+
+	//the third distinct reporter reaches the threshold, so the status flips
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "flaggedkey", "", "")
+	assert.Nil(t, err, "should not return error when a valid user reports")
+	stub.AssertCalled(t, "PutState", "flaggedkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Status == statusNeedsMaintenance
+	}))
+
+	//geoCachekey only has one earlier report, so the status stays unchanged
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "geoCachekey", "", "")
+	assert.Nil(t, err, "should not return error when a valid user reports")
+	stub.AssertCalled(t, "PutState", "geoCachekey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Status == ""
+	}))
+}
+
+func TestReportGeoCacheVisitRef(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+	stub.On("GetState", reportsEnabledKey).Return([]byte(nil), nil)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+	u.Salt = "123"
+
+	//a cache with one recorded visit
+	visitedCache := new(GeoCache)
+	visitedCache.Name = "visited cache"
+	visitedCache.VisitLog = []Visit{{User: *u, Xcoord: 6, Ycoord: 6, Timestamp: "1970-01-12T13:46:40Z"}}
+	visitedCacheBytes, _ := json.Marshal(visitedCache)
+	stub.On("GetState", "visitedkey").Return(visitedCacheBytes, nil)
+
+	//a report can reference a visit that really happened
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "visitedkey", "1970-01-12T13:46:40Z", "")
+	assert.Nil(t, err, "should not return error when the referenced visit exists")
+
+	//a reference to a visit that never happened is rejected
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "visitedkey", "2020-01-01T00:00:00Z", "")
+	assert.EqualError(t, err, "The referenced visit does not exist!", "should error when the referenced visit does not exist")
+}
+
+func TestSetReportsEnabled(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//the flag is stored disabled first, then enabled again
+	stub.On("GetState", reportsEnabledKey).Return([]byte("false"), nil).Once()
+	stub.On("GetState", reportsEnabledKey).Return([]byte("true"), nil)
+
+	//the admin disables reporting globally
+	err = c.SetReportsEnabled(ctx, false)
+	assert.Nil(t, err, "should not return error when the admin disables reporting")
+	stub.AssertCalled(t, "PutState", reportsEnabledKey, []byte("false"))
+
+	//reporting is rejected while the flag is disabled
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "geoCachekey", "", "")
+	assert.EqualError(t, err, "Reporting is currently disabled!", "should error while reporting is disabled")
+
+	//after re-enabling, reporting works again
+	err = c.SetReportsEnabled(ctx, true)
+	assert.Nil(t, err, "should not return error when the admin re-enables reporting")
+	stub.AssertCalled(t, "PutState", reportsEnabledKey, []byte("true"))
+
+	err = c.ReportGeoCache(ctx, *u, "reportMessage", "geoCachekey", "", "")
+	assert.Nil(t, err, "should not return error after reporting is re-enabled")
+
+	//a non admin caller can not toggle the flag
+	otherCtx, _ := configureStub()
+	err = c.SetReportsEnabled(otherCtx, false)
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error when a non admin toggles the flag")
+}
+
+func TestBlockUser(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+	stub.On("GetState", reportsEnabledKey).Return([]byte(nil), nil)
+
+	//a registered account that the admin blocks
+	blockedAccount := new(UserAccount)
+	blockedAccount.Name = "Blocked"
+	blockedAccount.RegisteredAt = 0
+	blockedAccount.Blocked = true
+	blockedBytes, _ := json.Marshal(blockedAccount)
+	stub.On("GetState", "user_blocked").Return(blockedBytes, nil)
+
+	blocked := new(User)
+	blocked.Id = "blocked"
+	blocked.Name = "Blocked"
+
+	//blocking stores the account with the blocked flag set
+	err = c.BlockUser(ctx, *blocked)
+	assert.Nil(t, err, "should not return error when an admin blocks a registered user")
+	stub.AssertCalled(t, "PutState", "user_blocked", blockedBytes)
+
+	//a blocked user can not visit or report a cache
+	err = c.AddVisitorToGeoCache(ctx, *blocked, "geoCachekey", 6, 6, "")
+	assert.EqualError(t, err, "The user Blocked is blocked!", "should error when a blocked user visits")
+
+	err = c.ReportGeoCache(ctx, *blocked, "reportMessage", "geoCachekey", "", "")
+	assert.EqualError(t, err, "The user Blocked is blocked!", "should error when a blocked user reports")
+
+	//a blocked user can not create a cache either
+	_, err = c.CreateGeoCache(ctx, *blocked, "missingkey", "some value", "testDescription", [2]int{5, 10}, [2]int{5, 10}, 2, 3, "regular", "asd", "", "", "")
+	assert.EqualError(t, err, "The user Blocked is blocked!", "should error when a blocked user creates")
+
+	//a non admin caller can not block users
+	otherCtx, _ := configureStub()
+	err = c.BlockUser(otherCtx, *blocked)
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error for a non admin caller")
+}
+
+func TestUnblockUser(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+
+	//an account that was blocked earlier
+	account := new(UserAccount)
+	account.Name = "Pardoned"
+	account.RegisteredAt = 0
+	account.Blocked = true
+	accountBytes, _ := json.Marshal(account)
+	stub.On("GetState", "user_pardoned").Return(accountBytes, nil)
+
+	pardoned := new(User)
+	pardoned.Id = "pardoned"
+	pardoned.Name = "Pardoned"
+
+	//unblocking stores the account with the blocked flag cleared
+	err = c.UnblockUser(ctx, *pardoned)
+	assert.Nil(t, err, "should not return error when an admin unblocks a registered user")
+
+	expectedAccount := new(UserAccount)
+	expectedAccount.Name = "Pardoned"
+	expectedAccount.RegisteredAt = 0
+	expectedAccountBytes, _ := json.Marshal(expectedAccount)
+	stub.AssertCalled(t, "PutState", "user_pardoned", expectedAccountBytes)
+}
+
+func TestAuditLedgerSchema(t *testing.T) {
+	//a healthy cache and one with a reversed coordinate range
+	healthyCache := new(GeoCache)
+	healthyCache.Name = "healthy cache"
+	healthyCache.XcoordRange = [2]int{5, 10}
+	healthyCache.YcoordRange = [2]int{5, 10}
+	healthyCacheBytes, _ := json.Marshal(healthyCache)
+
+	malformedCache := new(GeoCache)
+	malformedCache.Name = "malformed cache"
+	malformedCache.XcoordRange = [2]int{10, 5}
+	malformedCache.YcoordRange = [2]int{5, 10}
+	malformedCacheBytes, _ := json.Marshal(malformedCache)
+
+	//a held trackable record has no cache schema, but it is not a violation either
+	heldTrackable := Trackable{Id: "testId", Value: "testValue"}
+	heldTrackableBytes, _ := json.Marshal(heldTrackable)
+
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "healthykey", Value: healthyCacheBytes},
+		&queryresult.KV{Key: "held_123", Value: heldTrackableBytes},
+		//the reporting flag is stored as raw bytes, not as JSON
+		&queryresult.KV{Key: "config_reportsEnabled", Value: []byte("true")},
+		&queryresult.KV{Key: "malformedkey", Value: malformedCacheBytes},
+	), nil)
+
+	//only the malformed cache should be reported
+	violations, err := c.AuditLedgerSchema(ctx)
+	assert.Nil(t, err, "should not return error for an admin caller")
+	assert.Equal(t, []string{"malformedkey"}, violations, "should report only the malformed cache")
+
+	//a caller outside the admin organization should be rejected
+	otherCtx, _ := configureStub()
+	_, err = c.AuditLedgerSchema(otherCtx)
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error for a non admin caller")
+}
+
+func TestGetTrackableOwnershipChain(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	first := new(User)
+	first.Id = "123"
+	first.Name = "FirstHandler"
+
+	second := new(User)
+	second.Id = "456"
+	second.Name = "SecondHandler"
+
+	//a cache holding a trackable that two users moved in sequence
+	trackedCache := new(GeoCache)
+	trackedCache.Name = "tracked cache"
+	trackedCache.Trackable.Id = "travelbug"
+	trackedCache.Trackable.Value = "coin"
+	trackedCache.Trackable.History = append(trackedCache.Trackable.History, TrackableMove{ToCacheId: "otherkey", Timestamp: "1970-01-12T13:46:40Z", MovedBy: *first})
+	trackedCache.Trackable.History = append(trackedCache.Trackable.History, TrackableMove{FromCacheId: "otherkey", ToCacheId: "trackedkey", Timestamp: "1970-01-12T14:46:40Z", MovedBy: *second})
+	trackedCacheBytes, _ := json.Marshal(trackedCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "trackedkey", Value: trackedCacheBytes},
+	), nil)
+
+	//both handlers should be returned in the order of the moves
+	chain, err := c.GetTrackableOwnershipChain(ctx, "travelbug")
+	assert.Nil(t, err, "should not return error when the trackable exists")
+	assert.Equal(t, []User{*first, *second}, chain, "should return the handlers in move order")
+
+	//an unknown trackable id should return an error
+	ctx2, stub2 := configureStub()
+	stub2.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "trackedkey", Value: trackedCacheBytes},
+	), nil)
+
+	_, err = c.GetTrackableOwnershipChain(ctx2, "unknown")
+	assert.EqualError(t, err, "The trackable unknown does not exist", "should error when no cache holds the trackable")
+}
+
+func TestTransferOwnership(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	successor := new(User)
+	successor.Id = "789"
+	successor.Name = "Successor"
+
+	//someone other than the owner can not transfer the cache
+	err = c.TransferOwnership(ctx, *successor, "geoCachekey", *successor)
+	assert.EqualError(t, err, "Only the owner can transfer a cache!", "should error when not the owner transfers")
+
+	//the owner hands the cache over and the successor gets a fresh salted credential
+	err = c.TransferOwnership(ctx, *owner, "geoCachekey", *successor)
+	assert.Nil(t, err, "should not return error when the owner transfers")
+	stub.AssertCalled(t, "PutState", "geoCachekey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Owner.Name == "Successor" && storedCache.Owner.Id != "789" &&
+			storedCache.Owner.Salt != "" &&
+			verifyCredential(storedCache.Owner.Id, "789", storedCache.Owner.Salt)
+	}))
+}
+
+func TestTransferAllCaches(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	departing := new(User)
+	departing.Id = "123"
+	departing.Name = "Departing"
+
+	successor := new(User)
+	successor.Id = "789"
+	successor.Name = "Successor"
+
+	//two caches owned by the departing user, the stored id is myHash("123" + "123")
+	hashedOwner := User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "Departing", Salt: "123"}
+
+	firstCache := new(GeoCache)
+	firstCache.Name = "first cache"
+	firstCache.Owner = hashedOwner
+	firstCacheBytes, _ := json.Marshal(firstCache)
+
+	secondCache := new(GeoCache)
+	secondCache.Name = "second cache"
+	secondCache.Owner = hashedOwner
+	secondCacheBytes, _ := json.Marshal(secondCache)
+
+	//a cache of someone else that must stay untouched
+	foreignCache := new(GeoCache)
+	foreignCache.Name = "foreign cache"
+	foreignCache.Owner = User{Id: "someoneelse", Name: "Other", Salt: "xyz"}
+	foreignCacheBytes, _ := json.Marshal(foreignCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "firstkey", Value: firstCacheBytes},
+		&queryresult.KV{Key: "secondkey", Value: secondCacheBytes},
+		&queryresult.KV{Key: "foreignkey", Value: foreignCacheBytes},
+	), nil)
+
+	//both caches of the departing owner should be reassigned
+	count, err := c.TransferAllCaches(ctx, *departing, *successor)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Equal(t, 2, count, "should transfer both caches of the departing owner")
+
+	//the salts are random, so only the touched keys are asserted
+	stub.AssertCalled(t, "PutState", "firstkey", mock.AnythingOfType("[]uint8"))
+	stub.AssertCalled(t, "PutState", "secondkey", mock.AnythingOfType("[]uint8"))
+	stub.AssertNotCalled(t, "PutState", "foreignkey", mock.AnythingOfType("[]uint8"))
+}
+
+func TestMigrateVisitors(t *testing.T) {
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	u2 := new(User)
+	u2.Id = "456"
+	u2.Name = "OtherUser"
+
+	//a legacy cache that only has the plain visitors list
+	legacyCache := new(GeoCache)
+	legacyCache.Name = "legacy cache"
+	legacyCache.Visitors = []User{*u, *u2}
+	legacyCacheBytes, _ := json.Marshal(legacyCache)
+
+	//a cache that already keeps a visit log should be left alone
+	modernCache := new(GeoCache)
+	modernCache.Name = "modern cache"
+	modernCache.Visitors = []User{*u}
+	modernCache.VisitLog = append(modernCache.VisitLog, Visit{User: *u, Xcoord: 6, Ycoord: 6, Timestamp: "1970-01-12T13:46:40Z"})
+	modernCacheBytes, _ := json.Marshal(modernCache)
+
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "legacykey", Value: legacyCacheBytes},
+		&queryresult.KV{Key: "modernkey", Value: modernCacheBytes},
+	), nil)
+
+	//both legacy visitors should be converted
+	count, err := c.MigrateVisitors(ctx)
+	assert.Nil(t, err, "should not return error for an admin caller")
+	assert.Equal(t, 2, count, "should migrate both legacy visitor entries")
+
+	expectedCache := new(GeoCache)
+	expectedCache.Name = "legacy cache"
+	expectedCache.Visitors = []User{*u, *u2}
+	//the converted visits carry no timestamp, bc the original visit time is unknown
+	expectedCache.VisitLog = append(expectedCache.VisitLog, Visit{User: *u})
+	expectedCache.VisitLog = append(expectedCache.VisitLog, Visit{User: *u2})
+	expectedCache.AccessLog = append(expectedCache.AccessLog, AccessEntry{ClientId: "testClient", Operation: "MigrateVisitors", Timestamp: "1970-01-12T13:46:40Z"})
+	expectedCache.UpdatedAt = "1970-01-12T13:46:40Z"
+	expectedCache.Version = 1
+	expectedCacheBytes, _ := json.Marshal(expectedCache)
+
+	stub.AssertCalled(t, "PutState", "legacykey", expectedCacheBytes)
+	stub.AssertNotCalled(t, "PutState", "modernkey", mock.Anything)
+
+	//a caller outside the admin organization should be rejected
+	otherCtx, _ := configureStub()
+	_, err = c.MigrateVisitors(otherCtx)
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error for a non admin caller")
+}
+
+func TestGetAverageTimeToFirstVisit(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestOwner"
+
+	visitor := new(User)
+	visitor.Id = "456"
+	visitor.Name = "TestVisitor"
 
-	expectedGeoCache.Owner = *u2
-	//adding the new visitor
-	expectedGeoCache.Visitors = append(expectedGeoCache.Visitors, *u)
-	expectedGeoCache.XcoordRange = [2]int{5, 10}
-	expectedGeoCache.YcoordRange = [2]int{5, 10}
-	expectedGeoCacheBytes, _ := json.Marshal(expectedGeoCache)
+	//one cache found after 100 seconds, another after 300 seconds
+	quickCache := new(GeoCache)
+	quickCache.Name = "quick cache"
+	quickCache.Owner = *owner
+	quickCache.CreatedAt = "1970-01-12T13:46:40Z"
+	quickCache.VisitLog = append(quickCache.VisitLog, Visit{User: *visitor, Xcoord: 6, Ycoord: 6, Timestamp: "1970-01-12T13:48:20Z"})
+	quickCacheBytes, _ := json.Marshal(quickCache)
 
-	//put state should called with the expected cache value
-	stub.AssertCalled(t, "PutState", "geoCachekey", expectedGeoCacheBytes)
-}
+	slowCache := new(GeoCache)
+	slowCache.Name = "slow cache"
+	slowCache.Owner = *owner
+	slowCache.CreatedAt = "1970-01-12T13:46:40Z"
+	slowCache.VisitLog = append(slowCache.VisitLog, Visit{User: *visitor, Xcoord: 6, Ycoord: 6, Timestamp: "1970-01-12T13:51:40Z"})
+	slowCacheBytes, _ := json.Marshal(slowCache)
 
-func TestSwitchTrackable(t *testing.T) {
-	var err error
+	//a cache without visits should be skipped
+	unvisitedCache := new(GeoCache)
+	unvisitedCache.Name = "unvisited cache"
+	unvisitedCache.Owner = *owner
+	unvisitedCache.CreatedAt = "1970-01-12T13:46:40Z"
+	unvisitedCacheBytes, _ := json.Marshal(unvisitedCache)
 
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: quickCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: slowCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: unvisitedCacheBytes},
+	), nil)
+
+	//the average of 100 and 300 seconds is 200
+	average, err := c.GetAverageTimeToFirstVisit(ctx, "TestOwner")
+	assert.Nil(t, err, "should not return error when the owner has visited caches")
+	assert.Equal(t, float64(200), average, "should average the time to first visit of the visited caches")
+}
+
+func TestGetGeoCacheGeoJSON(t *testing.T) {
 	ctx, _ := configureStub()
 	c := new(GeoCacheContract)
 
-	trackable := new(Trackable)
-	trackable.Id = "testId"
-	trackable.Value = "testValue"
+	//a missing cache should return the usual not exist error
+	_, err := c.GetGeoCacheGeoJSON(ctx, "missingkey")
+	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when the cache does not exist")
 
-	// statebad returns nilBytes and an error, so the function should return with error
-	_, err = c.SwitchTrackable(ctx, *trackable, "statebad")
-	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+	//the fixture cache spans 5..10 on both axes
+	geoJSON, err := c.GetGeoCacheGeoJSON(ctx, "geoCachekey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.True(t, json.Valid([]byte(geoJSON)), "should return well formed JSON")
 
-	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
-	_, err = c.SwitchTrackable(ctx, *trackable, "missingkey")
-	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
+	//all four corners of the bounding box should be present
+	assert.Contains(t, geoJSON, "[5,5]")
+	assert.Contains(t, geoJSON, "[10,5]")
+	assert.Contains(t, geoJSON, "[10,10]")
+	assert.Contains(t, geoJSON, "[5,10]")
+	assert.Contains(t, geoJSON, `"name":"set value"`)
+}
 
-	// geoCachekey returns with a valid value and no error, so the function shouldnt return woth an error
-	switchedTrackable, err := c.SwitchTrackable(ctx, *trackable, "geoCachekey")
-	assert.Nil(t, err, "should not return error when GeoCache exists in world state when deleting")
+func TestGetGeoCacheHistory(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
 
-	expectedTrackable := new(Trackable)
-	expectedTrackable.Id = "testId"
-	expectedTrackable.Value = "testValue"
+	//two historical versions of the same cache, the older one with a stored salt
+	oldVersion := new(GeoCache)
+	oldVersion.Name = "old name"
+	oldVersion.Owner = User{Id: "somedigest", Name: "TestUser", Salt: "123"}
+	oldVersionBytes, _ := json.Marshal(oldVersion)
 
-	assert.Equal(t, switchedTrackable, expectedTrackable)
+	newVersion := new(GeoCache)
+	newVersion.Name = "new name"
+	newVersion.Owner = User{Id: "somedigest", Name: "TestUser", Salt: "123"}
+	newVersionBytes, _ := json.Marshal(newVersion)
+
+	stub.On("GetHistoryForKey", "historykey").Return(configureHistoryIterator(
+		&queryresult.KeyModification{TxId: "tx1", Value: oldVersionBytes, Timestamp: &timestamp.Timestamp{Seconds: 1000000}},
+		&queryresult.KeyModification{TxId: "tx2", Value: newVersionBytes, Timestamp: &timestamp.Timestamp{Seconds: 2000000}},
+	), nil)
+
+	history, err := c.GetGeoCacheHistory(ctx, "historykey")
+	assert.Nil(t, err, "should not return error when the history can be read")
+	assert.Len(t, history, 2, "should return both historical versions")
+
+	//the versions keep the order the peer served them in
+	assert.Equal(t, "tx1", history[0].TxId)
+	assert.Equal(t, "old name", history[0].Value.Name)
+	assert.Equal(t, "1970-01-12T13:46:40Z", history[0].Timestamp)
+	assert.Equal(t, "tx2", history[1].TxId)
+	assert.Equal(t, "new name", history[1].Value.Name)
+
+	//the stored salts must not leak from the history
+	assert.Equal(t, "", history[0].Value.Owner.Salt)
+	assert.Equal(t, "", history[1].Value.Owner.Salt)
 }
 
-func TestUpdateCoordGeoCache(t *testing.T) {
+func TestVerifyTrackableUniqueness(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//two caches claim the same trackable, a third holds its own
+	firstCache := new(GeoCache)
+	firstCache.Name = "first"
+	firstCache.Trackable = Trackable{Id: "sharedId", Value: "coin"}
+	firstCacheBytes, _ := json.Marshal(firstCache)
+
+	secondCache := new(GeoCache)
+	secondCache.Name = "second"
+	secondCache.Trackable = Trackable{Id: "sharedId", Value: "coin"}
+	secondCacheBytes, _ := json.Marshal(secondCache)
+
+	thirdCache := new(GeoCache)
+	thirdCache.Name = "third"
+	thirdCache.Trackable = Trackable{Id: "uniqueId", Value: "tag"}
+	thirdCacheBytes, _ := json.Marshal(thirdCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: firstCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: secondCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: thirdCacheBytes},
+	), nil).Once()
+
+	valid, duplicated, err := c.VerifyTrackableUniqueness(ctx)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.False(t, valid, "should report the ledger as invalid with a shared trackable")
+	assert.Equal(t, []string{"sharedId"}, duplicated, "should return the duplicated trackable id")
+
+	//a ledger where every trackable sits in one cache is valid
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: firstCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: thirdCacheBytes},
+	), nil)
+
+	valid, duplicated, err = c.VerifyTrackableUniqueness(ctx)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.True(t, valid, "should report a clean ledger as valid")
+	assert.Empty(t, duplicated, "should not return any duplicated ids")
+}
+
+func TestGetRecentStatusChanges(t *testing.T) {
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+
+	//three versions of the cache, the disable happened before the cutoff
+	activeVersion := new(GeoCache)
+	activeVersion.Status = statusActive
+	activeVersionBytes, _ := json.Marshal(activeVersion)
+
+	disabledVersion := new(GeoCache)
+	disabledVersion.Status = statusDisabled
+	disabledVersionBytes, _ := json.Marshal(disabledVersion)
+
+	archivedVersion := new(GeoCache)
+	archivedVersion.Status = statusArchived
+	archivedVersionBytes, _ := json.Marshal(archivedVersion)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "statuskey", Value: archivedVersionBytes},
+	), nil)
+	stub.On("GetHistoryForKey", "statuskey").Return(configureHistoryIterator(
+		&queryresult.KeyModification{TxId: "tx1", Value: activeVersionBytes, Timestamp: &timestamp.Timestamp{Seconds: 1000000}},
+		&queryresult.KeyModification{TxId: "tx2", Value: disabledVersionBytes, Timestamp: &timestamp.Timestamp{Seconds: 2000000}},
+		&queryresult.KeyModification{TxId: "tx3", Value: archivedVersionBytes, Timestamp: &timestamp.Timestamp{Seconds: 3000000}},
+	), nil)
+
+	//only the transition after the cutoff is reported
+	changes, err := c.GetRecentStatusChanges(ctx, 2500000)
+	assert.Nil(t, err, "should not return error for an admin caller")
+	assert.Len(t, changes, 1, "should only return the transitions after the cutoff")
+	assert.Equal(t, "statuskey", changes[0].CacheId)
+	assert.Equal(t, statusDisabled, changes[0].OldStatus)
+	assert.Equal(t, statusArchived, changes[0].NewStatus)
+	assert.Equal(t, formatTimestamp(3000000), changes[0].Timestamp)
+
+	//a caller outside the admin organization should be rejected
+	otherCtx, _ := configureStub()
+	changes, err = c.GetRecentStatusChanges(otherCtx, 0)
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error for a non admin caller")
+	assert.Nil(t, changes, "should not return changes for a non admin caller")
+}
+
+func TestExportGeoCacheGPX(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//a cache with GPS coordinates and an ampersand in its name
+	gpsCache := new(GeoCache)
+	gpsCache.Name = "Hills & Caves"
+	gpsCache.Description = "A nice spot"
+	gpsCache.Latitude = 47.4979
+	gpsCache.Longitude = 19.0402
+	gpsCacheBytes, _ := json.Marshal(gpsCache)
+	stub.On("GetState", "gpskey").Return(gpsCacheBytes, nil)
+
+	//the fixture cache has no GPS coordinates, so the export is rejected
+	_, err := c.ExportGeoCacheGPX(ctx, "geoCachekey")
+	assert.EqualError(t, err, "The cache geoCachekey has no GPS coordinates!", "should error without GPS coordinates")
+
+	gpx, err := c.ExportGeoCacheGPX(ctx, "gpskey")
+	assert.Nil(t, err, "should not return error when the cache has GPS coordinates")
+
+	//the document has to be well formed XML with a single waypoint
+	parsed := new(gpxDocument)
+	assert.Nil(t, xml.Unmarshal([]byte(gpx), parsed), "should return well formed XML")
+	assert.Len(t, parsed.Waypoints, 1, "should contain one waypoint")
+	assert.Equal(t, 47.4979, parsed.Waypoints[0].Latitude)
+	assert.Equal(t, 19.0402, parsed.Waypoints[0].Longitude)
+	assert.Equal(t, "Hills & Caves", parsed.Waypoints[0].Name)
+
+	//the raw string carries the escaped ampersand
+	assert.Contains(t, gpx, "Hills &amp; Caves")
+}
+
+func TestExportAllGeoCachesGPX(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//two GPS-enabled caches stored out of id order and one without coordinates
+	secondCache := new(GeoCache)
+	secondCache.Name = "second"
+	secondCache.Latitude = 48.2082
+	secondCache.Longitude = 16.3738
+	secondCacheBytes, _ := json.Marshal(secondCache)
+
+	firstCache := new(GeoCache)
+	firstCache.Name = "first"
+	firstCache.Latitude = 47.4979
+	firstCache.Longitude = 19.0402
+	firstCacheBytes, _ := json.Marshal(firstCache)
+
+	noGpsCache := new(GeoCache)
+	noGpsCache.Name = "no gps"
+	noGpsCacheBytes, _ := json.Marshal(noGpsCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cacheB", Value: secondCacheBytes},
+		&queryresult.KV{Key: "cacheA", Value: firstCacheBytes},
+		&queryresult.KV{Key: "cacheC", Value: noGpsCacheBytes},
+	), nil)
+
+	gpx, err := c.ExportAllGeoCachesGPX(ctx)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+
+	//only the GPS-enabled caches appear, ordered by their ids
+	parsed := new(gpxDocument)
+	assert.Nil(t, xml.Unmarshal([]byte(gpx), parsed), "should return well formed XML")
+	assert.Len(t, parsed.Waypoints, 2, "should only export the GPS-enabled caches")
+	assert.Equal(t, "first", parsed.Waypoints[0].Name)
+	assert.Equal(t, "second", parsed.Waypoints[1].Name)
+}
+
+func TestRefreshPopularityScore(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	fan := new(User)
+	fan.Id = "456"
+	fan.Name = "Fan"
+
+	//a visited cache before anyone favorited it: one visitor and one logged visit
+	popularCache := new(GeoCache)
+	popularCache.Name = "popular cache"
+	popularCache.Visitors = []User{*u}
+	popularCache.VisitLog = []Visit{{User: *u, Timestamp: "1970-01-12T13:46:40Z"}}
+	popularCacheBytes, _ := json.Marshal(popularCache)
+	//the exists check and the read both hit the same key
+	stub.On("GetState", "popularkey").Return(popularCacheBytes, nil).Twice()
+
+	score, err := c.RefreshPopularityScore(ctx, "popularkey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Equal(t, 2, score, "should count the visitor and the logged visit")
+
+	//after a new favorite the snapshot grows by the favorite weight
+	popularCache.FavoritedBy = []User{*fan}
+	favoritedBytes, _ := json.Marshal(popularCache)
+	stub.On("GetState", "popularkey").Return(favoritedBytes, nil)
+
+	score, err = c.RefreshPopularityScore(ctx, "popularkey")
+	assert.Nil(t, err, "should not return error when the cache exists")
+	assert.Equal(t, 4, score, "should weigh the new favorite double")
+	stub.AssertCalled(t, "PutState", "popularkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.PopularityScore == 4
+	}))
+}
+
+func TestGetMostPopularCaches(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	//three caches with stored popularity snapshots
+	quietCache := new(GeoCache)
+	quietCache.Name = "quiet"
+	quietCache.PopularityScore = 1
+	quietCacheBytes, _ := json.Marshal(quietCache)
+
+	busyCache := new(GeoCache)
+	busyCache.Name = "busy"
+	busyCache.PopularityScore = 7
+	busyCacheBytes, _ := json.Marshal(busyCache)
+
+	crowdedCache := new(GeoCache)
+	crowdedCache.Name = "crowded"
+	crowdedCache.PopularityScore = 9
+	crowdedCacheBytes, _ := json.Marshal(crowdedCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: quietCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: busyCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: crowdedCacheBytes},
+	), nil)
+
+	//the two most popular caches in descending score order
+	popularity, err := c.GetMostPopularCaches(ctx, 2)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, popularity, 2, "should honor the limit")
+	assert.Equal(t, CachePopularity{Id: "cache3", Score: 9}, popularity[0])
+	assert.Equal(t, CachePopularity{Id: "cache2", Score: 7}, popularity[1])
+}
+
+func TestGetTopRatedCaches(t *testing.T) {
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//a well rated cache with enough reviews
+	greatCache := new(GeoCache)
+	greatCache.Name = "great cache"
+	greatCache.Reviews = append(greatCache.Reviews, Review{Reviewer: *u, Stars: 5})
+	greatCache.Reviews = append(greatCache.Reviews, Review{Reviewer: *u, Stars: 4})
+	greatCacheBytes, _ := json.Marshal(greatCache)
+
+	//a five star cache with too few ratings to qualify
+	newCache := new(GeoCache)
+	newCache.Name = "new cache"
+	newCache.Reviews = append(newCache.Reviews, Review{Reviewer: *u, Stars: 5})
+	newCacheBytes, _ := json.Marshal(newCache)
+
+	//a mediocre cache with enough reviews
+	okCache := new(GeoCache)
+	okCache.Name = "ok cache"
+	okCache.Reviews = append(okCache.Reviews, Review{Reviewer: *u, Stars: 3})
+	okCache.Reviews = append(okCache.Reviews, Review{Reviewer: *u, Stars: 3})
+	okCacheBytes, _ := json.Marshal(okCache)
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "greatkey", Value: greatCacheBytes},
+		&queryresult.KV{Key: "newkey", Value: newCacheBytes},
+		&queryresult.KV{Key: "okkey", Value: okCacheBytes},
+	), nil)
+
+	//the unqualified cache is skipped even though it has the best average
+	ratings, err := c.GetTopRatedCaches(ctx, 2)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Equal(t, []CacheRating{{Id: "greatkey", AverageRating: 4.5}, {Id: "okkey", AverageRating: 3}}, ratings, "should return the qualified caches ordered by rating")
+}
+
+func TestGetCachesByReportCount(t *testing.T) {
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//three caches with two, zero and one open report
+	twiceReported := new(GeoCache)
+	twiceReported.Name = "twice reported"
+	twiceReported.Reports = append(twiceReported.Reports, Report{Id: "r1", Message: "first", Notifier: *u})
+	twiceReported.Reports = append(twiceReported.Reports, Report{Id: "r2", Message: "second", Notifier: *u})
+	twiceReportedBytes, _ := json.Marshal(twiceReported)
+
+	cleanCache := new(GeoCache)
+	cleanCache.Name = "clean"
+	cleanCacheBytes, _ := json.Marshal(cleanCache)
+
+	onceReported := new(GeoCache)
+	onceReported.Name = "once reported"
+	onceReported.Reports = append(onceReported.Reports, Report{Id: "r3", Message: "third", Notifier: *u})
+	onceReportedBytes, _ := json.Marshal(onceReported)
+
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cacheA", Value: twiceReportedBytes},
+		&queryresult.KV{Key: "cacheB", Value: cleanCacheBytes},
+		&queryresult.KV{Key: "cacheC", Value: onceReportedBytes},
+	), nil)
+
+	//with a limit of two only the two most reported caches are returned
+	counts, err := c.GetCachesByReportCount(ctx, 2)
+	assert.Nil(t, err, "should not return error for an admin caller")
+	assert.Equal(t, []CacheReportCount{{Id: "cacheA", Count: 2}, {Id: "cacheC", Count: 1}}, counts, "should return the top caches ordered by open report count")
+
+	//a caller outside the admin organization should be rejected
+	otherCtx, _ := configureStub()
+	_, err = c.GetCachesByReportCount(otherCtx, 2)
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error for a non admin caller")
+}
+
+func TestSearchReports(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+	owner.Salt = "123"
+
+	//a cache with a mixed set of reports, owned by the test user
+	hashedOwner := new(User)
+	hashedOwner.Id = "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba"
+	hashedOwner.Name = "TestUser"
+	hashedOwner.Salt = "123"
+
+	searchedCache := new(GeoCache)
+	searchedCache.Name = "searched cache"
+	searchedCache.Owner = *hashedOwner
+	searchedCache.Reports = append(searchedCache.Reports, Report{Id: "r1", Message: "The box is BROKEN", Notifier: *owner})
+	searchedCache.Reports = append(searchedCache.Reports, Report{Id: "r2", Message: "Missing logbook", Notifier: *owner})
+	searchedCacheBytes, _ := json.Marshal(searchedCache)
+	stub.On("GetState", "searchedkey").Return(searchedCacheBytes, nil)
+
+	//an empty keyword should be rejected before touching the world state
+	_, err := c.SearchReports(ctx, *owner, "searchedkey", "")
+	assert.EqualError(t, err, "The search keyword must not be empty!", "should error on an empty keyword")
+
+	//the keyword is matched case insensitively
+	matches, err := c.SearchReports(ctx, *owner, "searchedkey", "broken")
+	assert.Nil(t, err, "should not return error for the owner")
+	assert.Len(t, matches, 1, "should only return the matching report")
+	assert.Equal(t, "r1", matches[0].Id)
+
+	//someone other than the owner can not search the reports
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+	stranger.Salt = "123"
+
+	_, err = c.SearchReports(ctx, *stranger, "searchedkey", "broken")
+	assert.EqualError(t, err, "Only the owner can get the reports!", "should error for a non owner caller")
+}
+
+func TestReportGeoCacheAutoArchive(t *testing.T) {
 	var err error
 
 	ctx, stub := configureStub()
@@ -363,72 +4198,332 @@ func TestUpdateCoordGeoCache(t *testing.T) {
 	u := new(User)
 	u.Id = "123"
 	u.Name = "TestUser"
-	u.Salt = "123"
 
-	// statebad returns nilBytes and an error, so the function should return with error
-	err = c.UpdateCoordGeoCache(ctx, *u, "statebad", [2]int{7, 10}, [2]int{7, 10})
-	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+	stub.On("GetState", reportsEnabledKey).Return([]byte(nil), nil)
 
-	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
-	err = c.UpdateCoordGeoCache(ctx, *u, "missingkey", [2]int{7, 10}, [2]int{7, 10})
-	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
+	//a cache sitting right at the high severity threshold
+	strainedCache := new(GeoCache)
+	strainedCache.Name = "strained cache"
+	for i := 0; i < autoArchiveHighSeverityThreshold; i++ {
+		strainedCache.Reports = append(strainedCache.Reports, Report{Id: fmt.Sprintf("r%d", i), Message: "dangerous", Notifier: *u, Severity: severityHigh})
+	}
+	strainedCacheBytes, _ := json.Marshal(strainedCache)
+	stub.On("GetState", "strainedkey").Return(strainedCacheBytes, nil)
 
-	// geoCachekey returns with a valid value and no error, so the function shouldnt return woth an error
-	err = c.UpdateCoordGeoCache(ctx, *u, "geoCachekey", [2]int{7, 10}, [2]int{7, 10})
-	assert.Nil(t, err, "should not return error when GeoCache exists in world state when deleting")
+	//a cache far below the threshold
+	calmCache := new(GeoCache)
+	calmCache.Name = "calm cache"
+	calmCacheBytes, _ := json.Marshal(calmCache)
+	stub.On("GetState", "calmkey").Return(calmCacheBytes, nil)
 
-	expectedGeoCache := new(GeoCache)
-	expectedGeoCache.Name = "set value"
+	//the report crossing the threshold archives the cache in the same transaction
+	err = c.ReportGeoCache(ctx, *u, "broken glass", "strainedkey", "", severityHigh)
+	assert.Nil(t, err, "should not return error when the report can be stored")
+	stub.AssertCalled(t, "PutState", "strainedkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Status == statusArchived
+	}))
+	stub.AssertCalled(t, "SetEvent", "GeoCacheAutoArchived", mock.MatchedBy(func(payload []byte) bool {
+		event := new(GeoCacheAutoArchivedEvent)
+		json.Unmarshal(payload, event)
+		return event.CacheId == "strainedkey" && event.OpenHighSeverityReports == autoArchiveHighSeverityThreshold+1
+	}))
 
-	// expected user in the expected cache
-	u2 := new(User)
-	u2.Id = "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba"
-	u2.Name = "TestUser"
-	u2.Salt = "123"
+	//a single high severity report does not archive the cache
+	err = c.ReportGeoCache(ctx, *u, "broken glass", "calmkey", "", severityHigh)
+	assert.Nil(t, err, "should not return error when the report can be stored")
+	stub.AssertCalled(t, "PutState", "calmkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return storedCache.Status != statusArchived
+	}))
+	stub.AssertNotCalled(t, "SetEvent", "GeoCacheAutoArchived", mock.MatchedBy(func(payload []byte) bool {
+		event := new(GeoCacheAutoArchivedEvent)
+		json.Unmarshal(payload, event)
+		return event.CacheId == "calmkey"
+	}))
+}
 
-	trackable := new(Trackable)
-	expectedGeoCache.Trackable = *trackable
-	expectedGeoCache.Trackable.Id = "testId"
-	expectedGeoCache.Trackable.Value = "testValue"
+func TestGetSeverityDistribution(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
 
-	report := new(Report)
-	report.Id = "testId"
-	report.Message = "TestMessage"
-	report.Notifier = *u2
-	expectedGeoCache.Reports = append(expectedGeoCache.Reports, *report)
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
 
-	expectedGeoCache.Owner = *u2
-	//adding the new visitor
-	expectedGeoCache.XcoordRange = [2]int{7, 10}
-	expectedGeoCache.YcoordRange = [2]int{7, 10}
-	expectedGeoCacheBytes, _ := json.Marshal(expectedGeoCache)
+	//a cache with reports spread across the severity levels, owned by the test user
+	gradedCache := new(GeoCache)
+	gradedCache.Name = "graded cache"
+	gradedCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	gradedCache.Reports = append(gradedCache.Reports, Report{Id: "r1", Message: "soaked", Severity: severityHigh})
+	gradedCache.Reports = append(gradedCache.Reports, Report{Id: "r2", Message: "full logbook", Severity: severityLow})
+	gradedCache.Reports = append(gradedCache.Reports, Report{Id: "r3", Message: "lid cracked", Severity: severityHigh})
+	gradedCache.Reports = append(gradedCache.Reports, Report{Id: "r4", Message: "old report"})
+	gradedCacheBytes, _ := json.Marshal(gradedCache)
+	stub.On("GetState", "gradedkey").Return(gradedCacheBytes, nil)
 
-	//put state should called with the expected cache value
-	stub.AssertCalled(t, "PutState", "geoCachekey", expectedGeoCacheBytes)
+	//someone other than the owner can not read the distribution
+	stranger := new(User)
+	stranger.Id = "456"
+	stranger.Name = "Stranger"
+
+	_, err := c.GetSeverityDistribution(ctx, *stranger, "gradedkey")
+	assert.EqualError(t, err, "Only the owner can get the reports!", "should error for a non owner caller")
+
+	//the counts are grouped per severity, the legacy reports count as unspecified
+	distribution, err := c.GetSeverityDistribution(ctx, *owner, "gradedkey")
+	assert.Nil(t, err, "should not return error for the owner")
+	assert.Equal(t, map[string]int{severityHigh: 2, severityLow: 1, "UNSPECIFIED": 1}, distribution)
 }
 
-func TestReportGeoCache(t *testing.T) {
+func TestResolveReport(t *testing.T) {
 	var err error
 
-	ctx, _ := configureStub()
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	notifier := new(User)
+	notifier.Id = "456"
+	notifier.Name = "Notifier"
+
+	//a cache of the owner with one open report
+	handledCache := new(GeoCache)
+	handledCache.Name = "handled cache"
+	handledCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	handledCache.Reports = append(handledCache.Reports, Report{Id: "r1", Message: "wet logbook", Notifier: *notifier})
+	handledCacheBytes, _ := json.Marshal(handledCache)
+	stub.On("GetState", "handledkey").Return(handledCacheBytes, nil)
+
+	//only the owner can resolve a report
+	err = c.ResolveReport(ctx, *notifier, "handledkey", "r1", "swapped the logbook")
+	assert.EqualError(t, err, "Only the owner can resolve a report!", "should error when not the owner resolves")
+
+	//a missing report id is rejected before anything is stored
+	err = c.ResolveReport(ctx, *owner, "handledkey", "nosuchreport", "swapped the logbook")
+	assert.EqualError(t, err, "The report nosuchreport does not exist!", "should error on a missing report")
+
+	//resolving an existing report stores the resolution on it
+	err = c.ResolveReport(ctx, *owner, "handledkey", "r1", "swapped the logbook")
+	assert.Nil(t, err, "should not return error when the owner resolves an existing report")
+	stub.AssertCalled(t, "PutState", "handledkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.Reports) == 1 && storedCache.Reports[0].Resolved &&
+			storedCache.Reports[0].Resolution == "swapped the logbook" &&
+			storedCache.Reports[0].ResolvedAt == 1000000
+	}))
+}
+
+func TestGetOwnerResponsivenessRanking(t *testing.T) {
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
+
+	//the fast owner resolves in 100 and 300 seconds, the slow one in 1000
+	fastCache := new(GeoCache)
+	fastCache.Name = "fast cache"
+	fastCache.Owner = User{Id: "fastdigest", Name: "FastOwner", Salt: "123"}
+	fastCache.Reports = []Report{
+		{Id: "r1", Message: "m1", CreatedAt: 1000, Resolved: true, ResolvedAt: 1100},
+		{Id: "r2", Message: "m2", CreatedAt: 2000, Resolved: true, ResolvedAt: 2300},
+		{Id: "r3", Message: "m3", CreatedAt: 3000},
+	}
+	fastCacheBytes, _ := json.Marshal(fastCache)
+
+	slowCache := new(GeoCache)
+	slowCache.Name = "slow cache"
+	slowCache.Owner = User{Id: "slowdigest", Name: "SlowOwner", Salt: "456"}
+	slowCache.Reports = []Report{
+		{Id: "r4", Message: "m4", CreatedAt: 1000, Resolved: true, ResolvedAt: 2000},
+	}
+	slowCacheBytes, _ := json.Marshal(slowCache)
+
+	//a third owner without measurable reports stays out of the ranking
+	idleCache := new(GeoCache)
+	idleCache.Name = "idle cache"
+	idleCache.Owner = User{Id: "idledigest", Name: "IdleOwner", Salt: "789"}
+	idleCacheBytes, _ := json.Marshal(idleCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: slowCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: fastCacheBytes},
+		&queryresult.KV{Key: "cache3", Value: idleCacheBytes},
+	), nil)
+
+	ranking, err := c.GetOwnerResponsivenessRanking(ctx, 2)
+	assert.Nil(t, err, "should not return error for an admin caller")
+	assert.Len(t, ranking, 2, "should only rank the owners with resolved reports")
+	assert.Equal(t, "FastOwner", ranking[0].Owner, "should rank the fastest owner first")
+	assert.Equal(t, 200.0, ranking[0].AverageSeconds, "should average the resolution times")
+	assert.Equal(t, "SlowOwner", ranking[1].Owner, "should rank the slower owner second")
+	assert.Equal(t, 1000.0, ranking[1].AverageSeconds)
+
+	//a caller outside the admin organization should be rejected
+	otherCtx, _ := configureStub()
+	ranking, err = c.GetOwnerResponsivenessRanking(otherCtx, 2)
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error for a non admin caller")
+	assert.Nil(t, ranking, "should not return a ranking for a non admin caller")
+}
+
+func TestDeleteReport(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	notifier := new(User)
+	notifier.Id = "456"
+	notifier.Name = "Notifier"
+
+	//a cache of the owner with a spam report among the real ones
+	spammedCache := new(GeoCache)
+	spammedCache.Name = "spammed cache"
+	spammedCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	spammedCache.Reports = append(spammedCache.Reports, Report{Id: "r1", Message: "real problem", Notifier: *notifier})
+	spammedCache.Reports = append(spammedCache.Reports, Report{Id: "spam", Message: "buy cheap trackables", Notifier: *notifier})
+	spammedCacheBytes, _ := json.Marshal(spammedCache)
+	stub.On("GetState", "spammedkey").Return(spammedCacheBytes, nil)
+
+	//only the owner can delete a report
+	err = c.DeleteReport(ctx, *notifier, "spammedkey", "spam")
+	assert.EqualError(t, err, "Only the owner can delete a report!", "should error when not the owner deletes")
+
+	//a missing report id is rejected before anything is stored
+	err = c.DeleteReport(ctx, *owner, "spammedkey", "nosuchreport")
+	assert.EqualError(t, err, "The report nosuchreport does not exist!", "should error on a missing report")
+
+	//deleting the spam report keeps the real one
+	err = c.DeleteReport(ctx, *owner, "spammedkey", "spam")
+	assert.Nil(t, err, "should not return error when the owner deletes an existing report")
+	stub.AssertCalled(t, "PutState", "spammedkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.Reports) == 1 && storedCache.Reports[0].Id == "r1"
+	}))
+}
+
+func TestAssignReport(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
 	c := new(GeoCacheContract)
 
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	notifier := new(User)
+	notifier.Id = "456"
+	notifier.Name = "Notifier"
+
+	//a cache of the owner with one open report
+	assignedCache := new(GeoCache)
+	assignedCache.Name = "assigned cache"
+	assignedCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	assignedCache.Reports = append(assignedCache.Reports, Report{Id: "r1", Message: "broken lid", Notifier: *notifier})
+	assignedCacheBytes, _ := json.Marshal(assignedCache)
+	stub.On("GetState", "assignedkey").Return(assignedCacheBytes, nil)
+
+	//only the owner can assign a report
+	err = c.AssignReport(ctx, *notifier, "assignedkey", "r1", "Helper")
+	assert.EqualError(t, err, "Only the owner can assign a report!", "should error when not the owner assigns")
+
+	//a missing report id is rejected before anything is stored
+	err = c.AssignReport(ctx, *owner, "assignedkey", "nosuchreport", "Helper")
+	assert.EqualError(t, err, "The report nosuchreport does not exist!", "should error on a missing report")
+
+	//assigning an existing report stores the assignee on it
+	err = c.AssignReport(ctx, *owner, "assignedkey", "r1", "Helper")
+	assert.Nil(t, err, "should not return error when the owner assigns an existing report")
+	stub.AssertCalled(t, "PutState", "assignedkey", mock.MatchedBy(func(bytes []byte) bool {
+		storedCache := new(GeoCache)
+		json.Unmarshal(bytes, storedCache)
+		return len(storedCache.Reports) == 1 && storedCache.Reports[0].Assignee == "Helper"
+	}))
+}
+
+func TestArchiveStaleReports(t *testing.T) {
 	u := new(User)
 	u.Id = "123"
 	u.Name = "TestUser"
-	u.Salt = "123"
 
-	// statebad returns nilBytes and an error, so the function should return with error
-	err = c.ReportGeoCache(ctx, *u, "reportMessage", "statebad")
-	assert.EqualError(t, err, fmt.Sprintf("Could not read from world state. %s", getStateError), "should error when exists errors")
+	//a cache with one stale and one recent open report, the cutoff falls between them
+	reportedGeoCache := new(GeoCache)
+	reportedGeoCache.Name = "reported cache"
+	reportedGeoCache.Reports = append(reportedGeoCache.Reports, Report{Id: "stale", Message: "old", Notifier: *u, CreatedAt: 100})
+	reportedGeoCache.Reports = append(reportedGeoCache.Reports, Report{Id: "recent", Message: "new", Notifier: *u, CreatedAt: 900000})
+	reportedGeoCacheBytes, _ := json.Marshal(reportedGeoCache)
 
-	//missingkey returns with nilBytes and no error, so the function should return error, bc the object does not exists
-	err = c.ReportGeoCache(ctx, *u, "reportMessage", "missingkey")
-	assert.EqualError(t, err, "The asset missingkey does not exist", "should error when exists returns true when deleting")
+	ctx, stub := configureStubAs(adminMSPID)
+	c := new(GeoCacheContract)
 
-	// geoCachekey returns with a valid value and no error, so the function shouldnt return with an error
-	err = c.ReportGeoCache(ctx, *u, "reportMessage", "geoCachekey")
-	assert.Nil(t, err, "should not return error when GeoCache exists in world state when deleting")
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "reportedkey", Value: reportedGeoCacheBytes},
+	), nil)
+
+	//only the stale report should be archived
+	count, err := c.ArchiveStaleReports(ctx, 500000)
+	assert.Nil(t, err, "should not return error for an admin caller")
+	assert.Equal(t, 1, count, "should archive only the stale report")
+
+	expectedGeoCache := new(GeoCache)
+	expectedGeoCache.Name = "reported cache"
+	expectedGeoCache.Reports = append(expectedGeoCache.Reports, Report{Id: "stale", Message: "old", Notifier: *u, CreatedAt: 100, Archived: true})
+	expectedGeoCache.Reports = append(expectedGeoCache.Reports, Report{Id: "recent", Message: "new", Notifier: *u, CreatedAt: 900000})
+	expectedGeoCache.AccessLog = append(expectedGeoCache.AccessLog, AccessEntry{ClientId: "testClient", Operation: "ArchiveStaleReports", Timestamp: "1970-01-12T13:46:40Z"})
+	expectedGeoCache.UpdatedAt = "1970-01-12T13:46:40Z"
+	expectedGeoCache.Version = 1
+	expectedGeoCacheBytes, _ := json.Marshal(expectedGeoCache)
+
+	stub.AssertCalled(t, "PutState", "reportedkey", expectedGeoCacheBytes)
+
+	//a caller outside the admin organization should be rejected
+	otherCtx, _ := configureStub()
+	_, err = c.ArchiveStaleReports(otherCtx, 500000)
+	assert.EqualError(t, err, "Only the admin organization can call this function!", "should error for a non admin caller")
+}
+
+func TestGetReportsByNotifier(t *testing.T) {
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	u := new(User)
+	u.Id = "123"
+	u.Name = "TestUser"
+
+	//one cache holds a report of the user, the other only a report of someone else
+	reportedCache := new(GeoCache)
+	reportedCache.Name = "reported cache"
+	reportedCache.Reports = []Report{
+		{Id: "r1", Message: "muddy", Notifier: *u},
+		{Id: "r2", Message: "missing", Notifier: User{Id: "456", Name: "Other"}},
+	}
+	reportedCacheBytes, _ := json.Marshal(reportedCache)
+
+	cleanCache := new(GeoCache)
+	cleanCache.Name = "clean cache"
+	cleanCache.Reports = []Report{{Id: "r3", Message: "wet log", Notifier: User{Id: "456", Name: "Other"}}}
+	cleanCacheBytes, _ := json.Marshal(cleanCache)
+
+	stub.On("GetStateByRange", "", "").Return(configureIterator(
+		&queryresult.KV{Key: "cache1", Value: reportedCacheBytes},
+		&queryresult.KV{Key: "cache2", Value: cleanCacheBytes},
+	), nil)
+
+	reports, err := c.GetReportsByNotifier(ctx, *u)
+	assert.Nil(t, err, "should not return error when the world state can be read")
+	assert.Len(t, reports, 1, "should only return the reports of the user")
+	assert.Equal(t, "cache1", reports[0].CacheId, "should wrap the report with its cache id")
+	assert.Equal(t, "r1", reports[0].Report.Id)
 }
 
 func TestGetReports(t *testing.T) {
@@ -466,3 +4561,36 @@ func TestGetReports(t *testing.T) {
 
 	assert.Equal(t, *expectedReport, reports[0])
 }
+
+func TestGetVisitors(t *testing.T) {
+	var err error
+
+	ctx, stub := configureStub()
+	c := new(GeoCacheContract)
+
+	owner := new(User)
+	owner.Id = "123"
+	owner.Name = "TestUser"
+
+	visitor := new(User)
+	visitor.Id = "456"
+	visitor.Name = "Visitor"
+
+	//a cache of the owner with one recorded visitor
+	visitedCache := new(GeoCache)
+	visitedCache.Name = "visited cache"
+	visitedCache.Owner = User{Id: "4ebe56ee0099cc1af664ad67b3410c2b0a18cfba", Name: "TestUser", Salt: "123"}
+	visitedCache.Visitors = []User{*visitor}
+	visitedCacheBytes, _ := json.Marshal(visitedCache)
+	stub.On("GetState", "visitedkey").Return(visitedCacheBytes, nil)
+
+	//the owner gets the full visitor list
+	visitors, err := c.GetVisitors(ctx, *owner, "visitedkey")
+	assert.Nil(t, err, "should not return error for the owner")
+	assert.Equal(t, []User{*visitor}, visitors, "should return the visitor list")
+
+	//anybody else is rejected
+	visitors, err = c.GetVisitors(ctx, *visitor, "visitedkey")
+	assert.EqualError(t, err, "Only the owner can view visitors!", "should error for a non owner caller")
+	assert.Nil(t, visitors, "should not return the visitors for a non owner caller")
+}
@@ -6,29 +6,242 @@ package main
 
 // GeoCache stores a value
 type GeoCache struct {
-	Name        string
-	Description string
-	XcoordRange [2]int
-	YcoordRange [2]int
-	Owner       User
-	Reports     []Report
-	Visitors    []User
-	Trackable   Trackable
+	Id               string
+	Name             string
+	Description      string
+	XcoordRange      [2]int
+	YcoordRange      [2]int
+	//MinX, MaxX, MinY and MaxY mirror the range bounds as scalars, because the CouchDB
+	//selector syntax can not address the elements of an array field
+	MinX             int
+	MaxX             int
+	MinY             int
+	MaxY             int
+	Latitude         float64
+	Longitude        float64
+	//RadiusMeters is the find radius of a GPS based cache around its point
+	RadiusMeters     float64
+	Owner            User
+	Reports          []Report
+	//Visitors is kept populated alongside VisitLog for backward compatibility
+	Visitors         []User
+	VerifiedVisitors []User
+	Trackable        Trackable
+	VisitCode        string
+	Hint             string
+	AccessLog        []AccessEntry
+	VisitLog         []Visit
+	Reviews          []Review
+	FavoritedBy      []User
+	//FavoritePoints counts the favorite awards, it always matches the length of FavoritedBy
+	FavoritePoints   int
+	Tags             []string
+	//Prerequisites holds the ids of the caches that have to be found before this one
+	Prerequisites    []string
+	//PhotoHashes holds the content hashes of off-chain photos of the cache location
+	PhotoHashes      []string
+	//Logbook holds the free-text notes of the finders, separate from the visit records
+	Logbook          []LogEntry
+	MaintenanceLog   []MaintenanceEntry
+	CreatedAt        string
+	UpdatedAt        string
+	Status           string
+	Published        bool
+	PopularityScore  int
+	//Difficulty and Terrain rate the cache on the standard 1-5 scale, 0 means unrated
+	Difficulty       int
+	Terrain          int
+	//Size classifies the container, the empty legacy size counts as unclassified
+	Size             string
+	//Version grows by one on every mutation, so a client can detect concurrent updates
+	Version          int
+}
+
+// VisitorAddedEvent is the payload of the chaincode event emitted after a visit
+type VisitorAddedEvent struct {
+	CacheId     string
+	VisitorName string
+	Xcoord      int
+	Ycoord      int
+}
+
+// GeoCacheAutoArchivedEvent is the payload of the chaincode event emitted when a cache is archived automatically
+type GeoCacheAutoArchivedEvent struct {
+	CacheId                 string
+	OpenHighSeverityReports int
+}
+
+// LogEntry is one free-text note a finder left in the logbook of a cache
+type LogEntry struct {
+	Author    User
+	Text      string
+	Timestamp string
+}
+
+// MaintenanceEntry records one physical maintenance of a cache
+type MaintenanceEntry struct {
+	Note       string
+	Maintainer User
+	Timestamp  string
+}
+
+// GeoCacheHistoryEntry is one historical version of a cache from the ledger history
+type GeoCacheHistoryEntry struct {
+	TxId      string
+	Value     *GeoCache
+	Timestamp string
+	IsDelete  bool
+}
+
+// StatusChange records one lifecycle transition of a cache found in its key history
+type StatusChange struct {
+	CacheId   string
+	OldStatus string
+	NewStatus string
+	Timestamp string
+}
+
+// GeoCacheSummary is a lightweight listing view of a cache without the heavy collections
+type GeoCacheSummary struct {
+	Id            string
+	Name          string
+	Description   string
+	XcoordRange   [2]int
+	YcoordRange   [2]int
+	VisitorCount  int
+	AverageRating float64
+}
+
+// GeoCacheInput is one cache of a batch creation request
+type GeoCacheInput struct {
+	Id               string
+	Name             string
+	Description      string
+	XcoordRange      [2]int
+	YcoordRange      [2]int
+	Difficulty       int
+	Terrain          int
+	Size             string
+	TrackableValue   string
+	TrackableMission string
+	VisitCode        string
+	Hint             string
+	//SkipIfExists makes the batch step over this cache instead of failing when its id is taken
+	SkipIfExists bool
+}
+
+// PaginatedGeoCaches is one page of a cache listing
+type PaginatedGeoCaches struct {
+	Caches              []*GeoCache
+	Bookmark            string
+	FetchedRecordsCount int32
+}
+
+// UserProfile aggregates the activity of one user across every cache
+type UserProfile struct {
+	OwnedCaches   int
+	VisitedCaches int
+	FoundCaches   int
+	ReportsFiled  int
+	Favorites     int
+}
+
+// Review is one user rating of a cache
+type Review struct {
+	Reviewer  User
+	Stars     int
+	Comment   string
+	Timestamp string
+}
+
+// CacheRating pairs a cache id with its average rating
+type CacheRating struct {
+	Id            string
+	AverageRating float64
+}
+
+// Visit records one check-in of a user at a cache
+type Visit struct {
+	User      User
+	Xcoord    int
+	Ycoord    int
+	Timestamp string
 }
 
 type Trackable struct {
-	Id    string
-	Value string
+	Id      string
+	Value   string
+	Mission string
+	//DistanceTraveled accumulates the euclidean distance between the range centers of the visited caches
+	DistanceTraveled int
+	History          []TrackableMove
 }
 
+// TrackableMove records one movement of a trackable between caches
+type TrackableMove struct {
+	FromCacheId string
+	ToCacheId   string
+	Timestamp   string
+	MovedBy     User
+}
+
+// User identifies a player; Salt is only populated on stored owners and verified
+// visitors, where Id holds the salted credential digest instead of the raw id
 type User struct {
 	Id   string
 	Name string
 	Salt string
 }
 
+// UserAccount is the registered account record of a user in the world state
+type UserAccount struct {
+	Name         string
+	RegisteredAt int64
+	Blocked      bool
+}
+
+// CachePopularity pairs a cache id with its stored popularity score
+type CachePopularity struct {
+	Id    string
+	Score int
+}
+
+// OwnerResponsiveness pairs an owner name with the average seconds their reports took to resolve
+type OwnerResponsiveness struct {
+	Owner          string
+	AverageSeconds float64
+}
+
+// ReportWithCache pairs a report with the id of the cache it was filed on
+type ReportWithCache struct {
+	CacheId string
+	Report  Report
+}
+
+// CacheReportCount pairs a cache id with its open report count
+type CacheReportCount struct {
+	Id    string
+	Count int
+}
+
+// AccessEntry records one modification of a cache in its access log
+type AccessEntry struct {
+	ClientId  string
+	Operation string
+	Timestamp string
+}
+
 type Report struct {
-	Id       string
-	Message  string
-	Notifier User
+	Id         string
+	Message    string
+	Notifier   User
+	CreatedAt  int64
+	Archived   bool
+	VisitRef   string
+	Assignee   string
+	Severity   string
+	Resolved   bool
+	//ResolvedAt holds the unix time of the resolution, zero while the report is open
+	ResolvedAt int64
+	Resolution string
 }
@@ -3,7 +3,10 @@ module GeoCache
 go 1.13
 
 require (
+	github.com/golang/protobuf v1.3.2
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20200424173110-d7076418f212
 	github.com/hyperledger/fabric-contract-api-go v1.1.0
+	github.com/hyperledger/fabric-protos-go v0.0.0-20200424173316-dd554ba3746e
 	github.com/stretchr/testify v1.7.1
+	golang.org/x/crypto v0.17.0
 )